@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	confession "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/sentiment"
+)
+
+// Re-scores the sentiment of every stored confession with the built-in lexicon.
+// Safe to run repeatedly: rows whose label did not change are left alone.
+func main() {
+	batch := flag.Int("batch", 500, "rows per batch")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := config.InitDB(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	svc := confession.NewService(confession.NewRepo(db), sentiment.NewLexicon(), confession.Options{
+		HotTrending: cfg.Trending.UseHot,
+		Gravity:     cfg.Ranking.Gravity,
+	})
+	updated, err := svc.RescoreSentiment(context.Background(), *batch)
+	if err != nil {
+		log.Fatalf("sentiment backfill failed after %d updates: %v", updated, err)
+	}
+	fmt.Printf("sentiment backfill done: %d confessions updated\n", updated)
+}
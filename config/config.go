@@ -1,42 +1,224 @@
+// Package config loads the typed application configuration: built-in
+// defaults, overlaid by an optional YAML file, overlaid by environment
+// variables, validated once at startup.
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"time"
 
-	"gorm.io/driver/postgres"
+	"github.com/Balaji01-4D/shit-happens/internals/dialect"
+	"gopkg.in/yaml.v3"
 	"gorm.io/gorm"
 )
 
+// DefaultFile is read when CONFIG_FILE is not set; it may be absent
+const DefaultFile = "config.yaml"
+
 type Config struct {
-	DBUrl         string
-	ServerAddress string
+	Database   Database   `yaml:"database"`
+	Server     Server     `yaml:"server"`
+	Admin      Admin      `yaml:"admin"`
+	CORS       CORS       `yaml:"cors"`
+	RateLimit  RateLimits `yaml:"rateLimit"`
+	Pagination Pagination `yaml:"pagination"`
+	Trending   Trending   `yaml:"trending"`
+	Ranking    Ranking    `yaml:"ranking"`
+	Moderation Moderation `yaml:"moderation"`
+	Reactions  Reactions  `yaml:"reactions"`
+	Feeds      Feeds      `yaml:"feeds"`
+	Log        Log        `yaml:"log"`
+	Metrics    Metrics    `yaml:"metrics"`
+	Tracing    Tracing    `yaml:"tracing"`
+}
+
+type Database struct {
+	// URL is postgres://… or sqlite://path/to/file.db
+	URL string `yaml:"url" env:"DATABASE_URL"`
+	// AllowPendingMigrations starts the server even when the schema is behind
+	// the binary, e.g. while a migration job runs alongside the rollout
+	AllowPendingMigrations bool `yaml:"allowPendingMigrations"`
+}
+
+type Server struct {
+	// Port is a bare port number; ":8080" is accepted too
+	Port string `yaml:"port" env:"PORT"`
+	// ShutdownTimeout is how long in-flight requests may take to finish on SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+// Admin holds the basic auth credentials of the admin routes
+type Admin struct {
+	Username string `yaml:"username" env:"ADMIN_USERNAME"`
+	Password string `yaml:"password" env:"ADMIN_PASSWORD"`
+}
+
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowedOrigins"`
+}
+
+// Limit allows Requests per Per on average, with bursts of up to Burst
+type Limit struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"`
 }
 
-// func LoadEnvVariables() {
-// 	err := godotenv.Load()
+// RateLimits are per client: Posts covers confessions and comments (each on
+// its own budget), Reports covers reports, Votes covers upvotes and reactions
+type RateLimits struct {
+	Posts   Limit `yaml:"posts"`
+	Reports Limit `yaml:"reports"`
+	Votes   Limit `yaml:"votes"`
+}
+
+// PageSize bounds the ?limit= of an offset paginated listing
+type PageSize struct {
+	DefaultLimit int `yaml:"defaultLimit"`
+	MaxLimit     int `yaml:"maxLimit"`
+}
 
-// 	if err != nil {
-// 		log.Fatal("Error loading the .env files", err)
+type Pagination struct {
+	Confessions PageSize `yaml:"confessions"`
+	Comments    PageSize `yaml:"comments"`
+	Moderation  PageSize `yaml:"moderation"`
+	Tags        PageSize `yaml:"tags"`
+	Webhooks    PageSize `yaml:"webhooks"`
+}
 
-// 	}
-// }
+type Trending struct {
+	// UseHot orders the trending listings and feed by hot score instead of raw upvotes
+	UseHot bool `yaml:"useHot" env:"TRENDING_USE_HOT"`
+}
 
-// func init() {
-// 	LoadEnvVariables()
-// }
+type Ranking struct {
+	Gravity         float64       `yaml:"gravity" env:"HOT_GRAVITY"`
+	RefreshInterval time.Duration `yaml:"refreshInterval" env:"HOT_REFRESH_INTERVAL"`
+	Window          time.Duration `yaml:"window"`
+}
 
-func Load() *Config {
+type Moderation struct {
+	// ReportFlagThreshold is the number of reports that hides a confession
+	ReportFlagThreshold int `yaml:"reportFlagThreshold" env:"REPORT_FLAG_THRESHOLD"`
+}
+
+type Reactions struct {
+	Kinds []string `yaml:"kinds" env:"REACTION_KINDS"`
+}
+
+type Feeds struct {
+	// PublicBaseURL makes feed links absolute; the request host is used when empty
+	PublicBaseURL string        `yaml:"publicBaseURL" env:"PUBLIC_BASE_URL"`
+	CacheTTL      time.Duration `yaml:"cacheTTL" env:"FEED_CACHE_TTL"`
+}
 
+type Log struct {
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+}
+
+type Metrics struct {
+	RequireAdmin bool `yaml:"requireAdmin" env:"METRICS_REQUIRE_ADMIN"`
+}
+
+type Tracing struct {
+	// Exporter is none, otlp, stdout or its alias console; the OTLP endpoint
+	// comes from the standard OTEL_EXPORTER_OTLP_* variables
+	Exporter string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER"`
+}
+
+// Default is the configuration used for every setting neither the file nor
+// the environment mentions
+func Default() *Config {
 	return &Config{
-		DBUrl:         os.Getenv("DATABASE_URL"),
-		ServerAddress: os.Getenv("PORT"),
+		Server: Server{Port: "8080", ShutdownTimeout: 15 * time.Second},
+		CORS: CORS{
+			AllowedOrigins: []string{"https://shit-happens.vercel.app", "http://localhost:5173"},
+		},
+		RateLimit: RateLimits{
+			Posts:   Limit{Requests: 10, Per: time.Hour, Burst: 3},
+			Reports: Limit{Requests: 5, Per: time.Hour, Burst: 2},
+			Votes:   Limit{Requests: 1, Per: 10 * time.Second, Burst: 3},
+		},
+		Pagination: Pagination{
+			Confessions: PageSize{DefaultLimit: 10, MaxLimit: 100},
+			Comments:    PageSize{DefaultLimit: 20, MaxLimit: 100},
+			Moderation:  PageSize{DefaultLimit: 10, MaxLimit: 100},
+			Tags:        PageSize{DefaultLimit: 50, MaxLimit: 100},
+			Webhooks:    PageSize{DefaultLimit: 50, MaxLimit: 100},
+		},
+		Ranking: Ranking{
+			Gravity:         1.8, // Hacker News' value
+			RefreshInterval: 5 * time.Minute,
+			Window:          30 * 24 * time.Hour,
+		},
+		Moderation: Moderation{ReportFlagThreshold: 3},
+		Reactions:  Reactions{Kinds: []string{"been_there", "facepalm", "mind_blown", "rip"}},
+		Feeds:      Feeds{CacheTTL: 5 * time.Minute},
+		Log:        Log{Level: "info"},
+		Tracing:    Tracing{Exporter: "none"},
 	}
 }
 
-func InitDB(cfg *Config) *gorm.DB {
-	db, err := gorm.Open(postgres.Open(cfg.DBUrl), &gorm.Config{})
+// Load builds the configuration from the defaults, the YAML file named by
+// CONFIG_FILE (config.yaml when unset and present) and the environment, and
+// validates the result. Every invalid setting is reported, not just the first.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	path, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit {
+		path = DefaultFile
+	}
+	if path != "" {
+		err := cfg.loadFile(path)
+		if errors.Is(err, fs.ErrNotExist) && !explicit {
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	cfg.normalize()
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// loadFile overlays the YAML file; unknown keys are rejected so typos surface
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// InitDB connects to the database, PostgreSQL or SQLite depending on the URL;
+// a bad DSN or an unreachable server is returned as an error for the caller
+// to report
+func InitDB(cfg *Config) (*gorm.DB, error) {
+	dialector, err := dialect.Open(cfg.Database.URL)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("connecting to the database: %w", err)
 	}
-	return db
+	return db, nil
 }
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Balaji01-4D/shit-happens/internals/dialect"
+)
+
+// reaction kinds are stored in a size:20 column and used in URLs
+var reactionKind = regexp.MustCompile(`^[a-z][a-z0-9_]{0,19}$`)
+
+// normalize tidies values that have an obvious canonical form
+func (c *Config) normalize() {
+	c.Log.Level = strings.ToLower(strings.TrimSpace(c.Log.Level))
+	c.Tracing.Exporter = strings.ToLower(strings.TrimSpace(c.Tracing.Exporter))
+	c.Feeds.PublicBaseURL = strings.TrimRight(strings.TrimSpace(c.Feeds.PublicBaseURL), "/")
+
+	var kinds []string
+	for _, k := range c.Reactions.Kinds {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" && !slices.Contains(kinds, k) {
+			kinds = append(kinds, k)
+		}
+	}
+	c.Reactions.Kinds = kinds
+}
+
+// Validate reports every invalid setting, each prefixed with its YAML path
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(path, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: "+format, append([]any{path}, args...)...))
+	}
+
+	if c.Database.URL == "" {
+		fail("database.url", "is required (or set DATABASE_URL)")
+	} else if _, err := dialect.Open(c.Database.URL); err != nil {
+		fail("database.url", "%v", err)
+	}
+
+	if _, err := ListenAddr(c.Server.Port); err != nil {
+		fail("server.port", "%v", err)
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		fail("server.shutdownTimeout", "must be positive, got %s", c.Server.ShutdownTimeout)
+	}
+
+	// empty credentials would let an empty basic auth header in
+	if c.Admin.Username == "" {
+		fail("admin.username", "is required (or set ADMIN_USERNAME)")
+	}
+	if c.Admin.Password == "" {
+		fail("admin.password", "is required (or set ADMIN_PASSWORD)")
+	}
+
+	for i, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			fail(fmt.Sprintf("cors.allowedOrigins[%d]", i), "%q is not an origin like https://example.com", origin)
+		}
+	}
+
+	limits := []struct {
+		path string
+		Limit
+	}{{"rateLimit.posts", c.RateLimit.Posts}, {"rateLimit.reports", c.RateLimit.Reports}, {"rateLimit.votes", c.RateLimit.Votes}}
+	for _, l := range limits {
+		path := l.path
+		if l.Requests < 1 {
+			fail(path+".requests", "must be at least 1, got %d", l.Requests)
+		}
+		if l.Per <= 0 {
+			fail(path+".per", "must be positive, got %s", l.Per)
+		}
+		if l.Burst < 1 {
+			fail(path+".burst", "must be at least 1, got %d", l.Burst)
+		}
+	}
+
+	pages := []struct {
+		path string
+		PageSize
+	}{
+		{"pagination.confessions", c.Pagination.Confessions},
+		{"pagination.comments", c.Pagination.Comments},
+		{"pagination.moderation", c.Pagination.Moderation},
+		{"pagination.tags", c.Pagination.Tags},
+		{"pagination.webhooks", c.Pagination.Webhooks},
+	}
+	for _, p := range pages {
+		path := p.path
+		if p.DefaultLimit < 1 {
+			fail(path+".defaultLimit", "must be at least 1, got %d", p.DefaultLimit)
+		}
+		if p.MaxLimit < p.DefaultLimit {
+			fail(path+".maxLimit", "must be at least defaultLimit (%d), got %d", p.DefaultLimit, p.MaxLimit)
+		}
+	}
+
+	if c.Ranking.Gravity <= 0 {
+		fail("ranking.gravity", "must be positive, got %g", c.Ranking.Gravity)
+	}
+	if c.Ranking.RefreshInterval <= 0 {
+		fail("ranking.refreshInterval", "must be positive, got %s", c.Ranking.RefreshInterval)
+	}
+	if c.Ranking.Window <= 0 {
+		fail("ranking.window", "must be positive, got %s", c.Ranking.Window)
+	}
+
+	if c.Moderation.ReportFlagThreshold < 1 {
+		fail("moderation.reportFlagThreshold", "must be at least 1, got %d", c.Moderation.ReportFlagThreshold)
+	}
+
+	if len(c.Reactions.Kinds) == 0 {
+		fail("reactions.kinds", "needs at least one kind")
+	}
+	for _, k := range c.Reactions.Kinds {
+		if !reactionKind.MatchString(k) {
+			fail("reactions.kinds", "%q must be lowercase letters, digits or _, at most 20 characters", k)
+		}
+	}
+
+	if base := c.Feeds.PublicBaseURL; base != "" {
+		if u, err := url.Parse(base); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("feeds.publicBaseURL", "%q is not an absolute http(s) URL", base)
+		}
+	}
+	if c.Feeds.CacheTTL < 0 {
+		fail("feeds.cacheTTL", "must not be negative, got %s", c.Feeds.CacheTTL)
+	}
+
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, c.Log.Level) {
+		fail("log.level", "must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	if !slices.Contains([]string{"none", "otlp", "stdout", "console"}, c.Tracing.Exporter) {
+		fail("tracing.exporter", "must be none, otlp, stdout or console, got %q", c.Tracing.Exporter)
+	}
+
+	return errors.Join(errs...)
+}
+
+// ListenAddr turns the port setting into an address for http.Server, the way
+// gin's r.Run() treats PORT: a bare port number, ":8080" is accepted too
+func ListenAddr(port string) (string, error) {
+	addr := port
+	if !strings.Contains(addr, ":") {
+		addr = ":" + addr
+	}
+	_, p, err := net.SplitHostPort(addr)
+	if n, convErr := strconv.Atoi(p); err != nil || convErr != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("%q is not a port number", port)
+	}
+	return addr, nil
+}
@@ -0,0 +1,154 @@
+package comment
+
+import (
+	"net/http"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	confession "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/middleware"
+	"github.com/Balaji01-4D/shit-happens/internals/pagination"
+	"github.com/Balaji01-4D/shit-happens/internals/params"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
+	"github.com/Balaji01-4D/shit-happens/internals/upvote"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	repo := NewRepo(db)
+	svc := NewService(repo)
+	authorOrAdmin := middleware.AuthorOrAdmin(cfg.Admin, confession.NewRepo(db).CanManage)
+
+	// threaded comments: top-level comments are paginated, replies come nested
+	r.GET("/confessions/:id/comments", func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		offset, limit := pagination.Parse(c, cfg.Pagination.Comments)
+		comments, total, err := svc.Thread(c.Request.Context(), id, offset, limit)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "confession not found")
+				return
+			}
+			problem.Internal(c, err, "failed to fetch comments")
+			return
+		}
+		pagination.Offset(c, comments, offset, limit, int64(total), pagination.Wants(c))
+	})
+
+	r.POST("/confessions/:id/comments", middleware.PostRateLimitMiddleWare(cfg.RateLimit.Posts), func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		var dto CommentRequest
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			problem.InvalidBody(c, err)
+			return
+		}
+
+		comment, err := svc.Create(c.Request.Context(), id, dto)
+		if err != nil {
+			switch err {
+			case gorm.ErrRecordNotFound:
+				problem.NotFound(c, "confession not found")
+			case ErrParentMismatch:
+				problem.Respond(c, http.StatusBadRequest, problem.CodeParentMismatch, err.Error())
+			case ErrTooDeep:
+				problem.Respond(c, http.StatusBadRequest, problem.CodeThreadTooDeep, err.Error())
+			default:
+				problem.Internal(c, err, "failed to create")
+			}
+			return
+		}
+		c.JSON(http.StatusCreated, comment)
+	})
+
+	// the author marks a comment as the fix, which makes the confession solved
+	r.PUT("/confessions/:id/accepted-comment", authorOrAdmin, func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		var dto AcceptRequest
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			problem.InvalidBody(c, err)
+			return
+		}
+		if err := svc.Accept(c.Request.Context(), id, dto.CommentID); err != nil {
+			switch err {
+			case gorm.ErrRecordNotFound:
+				problem.NotFound(c, "confession or comment not found")
+			case ErrNotOnConfession:
+				problem.Respond(c, http.StatusBadRequest, problem.CodeNotOnConfession, err.Error())
+			default:
+				problem.Internal(c, err, "failed to accept")
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "comment accepted"})
+	})
+
+	r.DELETE("/confessions/:id/accepted-comment", authorOrAdmin, func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		if err := svc.Unaccept(c.Request.Context(), id); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "confession not found")
+				return
+			}
+			problem.Internal(c, err, "failed to unaccept")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "accepted comment cleared"})
+	})
+
+	r.POST("/comments/:id/upvote", middleware.UpvoteRateLimitMiddleware(cfg.RateLimit.Votes), func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		ipHash, clientHash := upvote.VoterHashes(c)
+
+		if repo.HasUpvoted(c.Request.Context(), id, ipHash, clientHash) {
+			c.JSON(http.StatusOK, gin.H{"message": "already upvoted"})
+			return
+		}
+
+		if err := svc.Upvote(c.Request.Context(), id, ipHash, clientHash); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "comment not found")
+				return
+			}
+			// Possible race: re-check; if now present, treat as idempotent success
+			if repo.HasUpvoted(c.Request.Context(), id, ipHash, clientHash) {
+				c.JSON(http.StatusOK, gin.H{"message": "already upvoted"})
+				return
+			}
+			problem.Internal(c, err, "failed to upvote")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "upvote recorded"})
+	})
+
+	// admin removes a comment and its whole reply thread
+	r.DELETE("/comments/:id", middleware.AdminAuthMiddleware(cfg.Admin), func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		if err := svc.Delete(c.Request.Context(), id); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "comment not found")
+				return
+			}
+			problem.Internal(c, err, "failed to delete")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "successfully deleted"})
+	})
+}
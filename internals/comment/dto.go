@@ -0,0 +1,10 @@
+package comment
+
+type CommentRequest struct {
+	Body     string `json:"body" binding:"required,notblank,max=10000"`
+	ParentID *uint  `json:"parentId"`
+}
+
+type AcceptRequest struct {
+	CommentID uint `json:"commentId" binding:"required"`
+}
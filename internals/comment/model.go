@@ -0,0 +1,26 @@
+package comment
+
+import "time"
+
+// Comment is a reply to a confession, or to another comment when ParentID is
+// set. Body is markdown and is stored as written; clients render it.
+type Comment struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	ConfessionID uint       `gorm:"index;not null" json:"confessionId"`
+	ParentID     *uint      `gorm:"index" json:"parentId"`
+	Depth        int        `gorm:"default:0" json:"depth"`
+	Body         string     `gorm:"type:text;not null" json:"body"`
+	Upvotes      int        `gorm:"default:0" json:"upvotes"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	Replies      []*Comment `gorm:"-" json:"replies"`
+}
+
+// CommentUpvote dedupes comment votes the same way upvote.Upvote does for
+// confessions: one vote per (comment, IP) and per (comment, client cookie).
+type CommentUpvote struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CommentID  uint      `gorm:"uniqueIndex:idx_cupvote_comment_ip;uniqueIndex:idx_cupvote_comment_client" json:"commentId"`
+	IPHash     string    `gorm:"size:64;uniqueIndex:idx_cupvote_comment_ip" json:"-"`
+	ClientHash string    `gorm:"size:64;uniqueIndex:idx_cupvote_comment_client" json:"-"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
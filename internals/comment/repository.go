@@ -0,0 +1,176 @@
+package comment
+
+import (
+	"context"
+
+	confession "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/upvote"
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	DB *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// ConfessionExists reports whether a visible confession can be commented on
+func (r *Repository) ConfessionExists(ctx context.Context, id uint) bool {
+	var count int64
+	r.DB.WithContext(ctx).Model(&confession.Confession{}).Where("id = ? AND is_flagged = ?", id, false).Count(&count)
+	return count > 0
+}
+
+// SetAccepted marks the comment as the confession's fix, or clears the mark when commentID is nil
+func (r *Repository) SetAccepted(ctx context.Context, confessionID uint, commentID *uint) error {
+	res := r.DB.WithContext(ctx).Model(&confession.Confession{}).
+		Where("id = ?", confessionID).
+		Updates(map[string]any{"solved": commentID != nil, "accepted_comment_id": commentID})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *Repository) Get(ctx context.Context, id uint) (Comment, error) {
+	var comment Comment
+	err := r.DB.WithContext(ctx).First(&comment, id).Error
+	return comment, err
+}
+
+// Create stores the comment and bumps the confession's comment count
+func (r *Repository) Create(ctx context.Context, comment *Comment) error {
+	tx := r.DB.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	if err := tx.Create(comment).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&confession.Confession{}).
+		Where("id = ?", comment.ConfessionID).
+		UpdateColumn("comment_count", gorm.Expr("comment_count + 1")).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// ListRoots returns a page of the confession's top-level comments, oldest first
+func (r *Repository) ListRoots(ctx context.Context, confessionID uint, offset, limit int) ([]Comment, error) {
+	var comments []Comment
+	err := r.DB.WithContext(ctx).
+		Where("confession_id = ? AND parent_id IS NULL", confessionID).
+		Order("created_at ASC, id ASC").
+		Offset(offset).Limit(limit).
+		Find(&comments).Error
+	return comments, err
+}
+
+func (r *Repository) CountRoots(ctx context.Context, confessionID uint) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&Comment{}).
+		Where("confession_id = ? AND parent_id IS NULL", confessionID).
+		Count(&n).Error
+	return n, err
+}
+
+// ListReplies returns the direct replies to the given comments, oldest first
+func (r *Repository) ListReplies(ctx context.Context, parentIDs []uint) ([]Comment, error) {
+	var comments []Comment
+	err := r.DB.WithContext(ctx).
+		Where("parent_id IN ?", parentIDs).
+		Order("created_at ASC, id ASC").
+		Find(&comments).Error
+	return comments, err
+}
+
+// Delete removes the comment together with all of its replies and their
+// votes, and lowers the confession's comment count accordingly
+func (r *Repository) Delete(ctx context.Context, id uint) error {
+	tx := r.DB.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	var root Comment
+	if err := tx.First(&root, id).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// walk the thread level by level; depth is capped so this stays short
+	ids := []uint{root.ID}
+	for level := []uint{root.ID}; len(level) > 0; {
+		var children []uint
+		if err := tx.Model(&Comment{}).Where("parent_id IN ?", level).Pluck("id", &children).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		ids = append(ids, children...)
+		level = children
+	}
+
+	if err := tx.Where("comment_id IN ?", ids).Delete(&CommentUpvote{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Where("id IN ?", ids).Delete(&Comment{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&confession.Confession{}).
+		Where("id = ?", root.ConfessionID).
+		UpdateColumn("comment_count", gorm.Expr("comment_count - ?", len(ids))).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	// the accepted fix may have been part of the removed thread
+	if err := tx.Model(&confession.Confession{}).
+		Where("id = ? AND accepted_comment_id IN ?", root.ConfessionID, ids).
+		Updates(map[string]any{"solved": false, "accepted_comment_id": nil}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// HasUpvoted checks whether the voter already upvoted the comment by IP or client hash
+func (r *Repository) HasUpvoted(ctx context.Context, commentID uint, ipHash, clientHash string) bool {
+	var vote CommentUpvote
+	err := r.DB.WithContext(ctx).Where("comment_id = ?", commentID).
+		Scopes(upvote.ByVoter(ipHash, clientHash)).
+		First(&vote).Error
+	return err == nil
+}
+
+// Upvote records the vote and bumps the counter; a duplicate vote fails on the
+// unique indexes and leaves the counter untouched
+func (r *Repository) Upvote(ctx context.Context, upvote *CommentUpvote) error {
+	tx := r.DB.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	if err := tx.Create(upvote).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&Comment{}).
+		Where("id = ?", upvote.CommentID).
+		UpdateColumn("upvotes", gorm.Expr("upvotes + 1")).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
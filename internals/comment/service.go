@@ -0,0 +1,150 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Balaji01-4D/shit-happens/internals/tracing"
+	"gorm.io/gorm"
+)
+
+// deepest reply level; keeps threads readable and deletes cheap
+const MaxDepth = 8
+
+var (
+	ErrParentMismatch  = errors.New("parent comment belongs to another confession")
+	ErrTooDeep         = errors.New("reply nesting too deep")
+	ErrNotOnConfession = errors.New("comment belongs to another confession")
+)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(r *Repository) *Service {
+	return &Service{repo: r}
+}
+
+func (s *Service) Create(ctx context.Context, confessionID uint, dto CommentRequest) (Comment, error) {
+	ctx, span := tracing.Start(ctx, "comment.Service.Create")
+	defer span.End()
+	if !s.repo.ConfessionExists(ctx, confessionID) {
+		return Comment{}, gorm.ErrRecordNotFound
+	}
+
+	comment := Comment{
+		ConfessionID: confessionID,
+		Body:         strings.TrimSpace(dto.Body),
+		CreatedAt:    time.Now(),
+		Replies:      []*Comment{},
+	}
+	if dto.ParentID != nil {
+		parent, err := s.repo.Get(ctx, *dto.ParentID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return Comment{}, ErrParentMismatch
+			}
+			return Comment{}, err
+		}
+		if parent.ConfessionID != confessionID {
+			return Comment{}, ErrParentMismatch
+		}
+		if parent.Depth+1 > MaxDepth {
+			return Comment{}, ErrTooDeep
+		}
+		comment.ParentID = &parent.ID
+		comment.Depth = parent.Depth + 1
+	}
+
+	if err := s.repo.Create(ctx, &comment); err != nil {
+		return Comment{}, err
+	}
+	return comment, nil
+}
+
+// Thread returns a window of top-level comments, oldest first, each with its
+// full reply tree, plus the number of top-level comments
+func (s *Service) Thread(ctx context.Context, confessionID uint, offset, limit int) ([]*Comment, int, error) {
+	ctx, span := tracing.Start(ctx, "comment.Service.Thread")
+	defer span.End()
+	if !s.repo.ConfessionExists(ctx, confessionID) {
+		return nil, 0, gorm.ErrRecordNotFound
+	}
+	total, err := s.repo.CountRoots(ctx, confessionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	roots, err := s.repo.ListRoots(ctx, confessionID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// only the replies under this page, one level per query
+	thread := make([]*Comment, 0, len(roots))
+	byID := make(map[uint]*Comment, len(roots))
+	level := make([]uint, 0, len(roots))
+	for i := range roots {
+		roots[i].Replies = []*Comment{}
+		thread = append(thread, &roots[i])
+		byID[roots[i].ID] = &roots[i]
+		level = append(level, roots[i].ID)
+	}
+	for len(level) > 0 {
+		replies, err := s.repo.ListReplies(ctx, level)
+		if err != nil {
+			return nil, 0, err
+		}
+		level = make([]uint, 0, len(replies))
+		for i := range replies {
+			c := &replies[i]
+			c.Replies = []*Comment{}
+			byID[c.ID] = c
+			byID[*c.ParentID].Replies = append(byID[*c.ParentID].Replies, c)
+			level = append(level, c.ID)
+		}
+	}
+	return thread, int(total), nil
+}
+
+// Accept marks one of the confession's comments as the fix
+func (s *Service) Accept(ctx context.Context, confessionID, commentID uint) error {
+	ctx, span := tracing.Start(ctx, "comment.Service.Accept")
+	defer span.End()
+	comment, err := s.repo.Get(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.ConfessionID != confessionID {
+		return ErrNotOnConfession
+	}
+	return s.repo.SetAccepted(ctx, confessionID, &comment.ID)
+}
+
+// Unaccept reopens the confession
+func (s *Service) Unaccept(ctx context.Context, confessionID uint) error {
+	ctx, span := tracing.Start(ctx, "comment.Service.Unaccept")
+	defer span.End()
+	return s.repo.SetAccepted(ctx, confessionID, nil)
+}
+
+func (s *Service) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "comment.Service.Delete")
+	defer span.End()
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *Service) Upvote(ctx context.Context, commentID uint, ipHash, clientHash string) error {
+	ctx, span := tracing.Start(ctx, "comment.Service.Upvote")
+	defer span.End()
+	if _, err := s.repo.Get(ctx, commentID); err != nil {
+		return err
+	}
+	return s.repo.Upvote(ctx, &CommentUpvote{
+		CommentID:  commentID,
+		IPHash:     ipHash,
+		ClientHash: clientHash,
+		CreatedAt:  time.Now(),
+	})
+}
@@ -1,95 +1,206 @@
 package confession
 
 import (
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/events"
 	"github.com/Balaji01-4D/shit-happens/internals/middleware"
+	"github.com/Balaji01-4D/shit-happens/internals/pagination"
+	"github.com/Balaji01-4D/shit-happens/internals/params"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
+	"github.com/Balaji01-4D/shit-happens/internals/sentiment"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-const (
-	defaultLimit = 10
-	maxLimit     = 100
-)
+// parsePage reads offset/limit, switching to keyset pagination when a cursor
+// parameter is present (an empty cursor requests the first page), and the
+// ?reaction= sort override
+func parsePage(c *gin.Context, cfg *config.Config) (Page, bool) {
+	offset, limit := pagination.Parse(c, cfg.Pagination.Confessions)
+	page := Page{Offset: offset, Limit: limit}
+	if kind := strings.ToLower(strings.TrimSpace(c.Query("reaction"))); kind != "" {
+		if !slices.Contains(cfg.Reactions.Kinds, kind) {
+			problem.Respond(c, http.StatusBadRequest, problem.CodeUnknownReaction, "unknown reaction "+kind)
+			return page, false
+		}
+		page.Reaction = kind
+	}
+	if raw, ok := c.GetQuery("cursor"); ok {
+		page.Keyset = true
+		if raw != "" {
+			after, err := DecodeCursor(raw)
+			if err != nil {
+				problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidCursor, "invalid cursor")
+				return page, false
+			}
+			page.After = after
+		}
+	}
+	return page, true
+}
 
-func parsePagination(c *gin.Context) (offset, limit int) {
-	offset, _ = strconv.Atoi(c.Query("offset"))
-	limit, _ = strconv.Atoi(c.Query("limit"))
-	if offset < 0 {
-		offset = 0
+// respondList writes a page of confessions: a bare array by default, the
+// {items, total, ...} envelope on request, {items, nextCursor} in keyset mode.
+// count is only run when the envelope needs the total.
+func respondList[T any](c *gin.Context, page Page, items []T, next string, count func() (int64, error)) {
+	envelope := pagination.Wants(c)
+	total := int64(-1)
+	if envelope {
+		n, err := count()
+		if err != nil {
+			problem.Internal(c, err, "failed to count")
+			return
+		}
+		total = n
 	}
-	if limit <= 0 {
-		limit = defaultLimit
+
+	if !page.Keyset {
+		pagination.Offset(c, items, page.Offset, page.Limit, total, envelope)
+		return
+	}
+
+	if items == nil {
+		items = []T{}
 	}
-	if limit > maxLimit {
-		limit = maxLimit
+	nextURL := pagination.CursorLink(c, next)
+	if envelope {
+		c.JSON(http.StatusOK, pagination.Envelope[T]{
+			Items: items, Total: total, Limit: page.Limit, Next: nextURL, NextCursor: next,
+		})
+		return
 	}
-	return
+	c.JSON(http.StatusOK, CursorPage[T]{Items: items, NextCursor: next})
 }
 
-func RegisterRoutes(r *gin.Engine, db *gorm.DB) {
+// listError maps a listing failure to a response; a cursor from another listing is a client error
+func listError(c *gin.Context, err error, msg string) {
+	if errors.Is(err, ErrInvalidCursor) {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidCursor, "invalid cursor")
+		return
+	}
+	problem.Internal(c, err, msg)
+}
+
+func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	repo := NewRepo(db)
-	service := NewService(repo)
+	service := NewService(repo, sentiment.NewLexicon(), Options{
+		HotTrending: cfg.Trending.UseHot,
+		Gravity:     cfg.Ranking.Gravity,
+		Publisher:   events.Default,
+	})
 
 	confessionRoutes := r.Group("/confessions")
 
 	confessionRoutes.GET("", func(c *gin.Context) {
-		offset, limit := parsePagination(c)
-		list, err := service.List(offset, limit)
+		mood := strings.ToLower(strings.TrimSpace(c.Query("sentiment")))
+		if mood != "" && !sentiment.IsValid(mood) {
+			problem.BadRequest(c, "sentiment must be positive, negative or neutral")
+			return
+		}
+		page, ok := parsePage(c, cfg)
+		if !ok {
+			return
+		}
+		list, err := service.List(c.Request.Context(), page, mood)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list"})
+			listError(c, err, "failed to fetch confessions")
 			return
 		}
-		c.JSON(http.StatusOK, list)
+		respondList(c, page, list, NextCursor(page, orderRecent, list), func() (int64, error) {
+			return service.CountList(c.Request.Context(), mood)
+		})
 	})
 
 	confessionRoutes.GET("/:id", func(c *gin.Context) {
-		id, err := strconv.Atoi(c.Param("id"))
-		if err != nil || id <= 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		id, ok := params.ID(c)
+		if !ok {
 			return
 		}
-		confession, err := service.Get(uint(id))
+		confession, err := service.Get(c.Request.Context(), id)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+				problem.NotFound(c, "confession not found")
 				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch"})
+			problem.Internal(c, err, "failed to fetch confession")
 			return
 		}
 		c.JSON(http.StatusOK, confession)
 	})
 
-	confessionRoutes.POST("", middleware.PostRateLimitMiddleWare(), func(c *gin.Context) {
+	confessionRoutes.POST("", middleware.PostRateLimitMiddleWare(cfg.RateLimit.Posts), func(c *gin.Context) {
 		var dto ConfessionRequest
 		if err := c.ShouldBindJSON(&dto); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			problem.InvalidBody(c, err)
 			return
 		}
-		confession, err := service.Create(dto)
+		confession, token, err := service.Create(c.Request.Context(), dto)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create"})
+			problem.Internal(c, err, "failed to create")
 			return
 		}
-		c.JSON(http.StatusCreated, confession)
+		c.JSON(http.StatusCreated, CreateConfessionResponse{Confession: confession, ManageToken: token})
 	})
 
-	confessionRoutes.DELETE("/:id", middleware.AdminAuthMiddleware(), func(c *gin.Context) {
-		id, err := strconv.Atoi(c.Param("id"))
-		if err != nil || id <= 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	confessionRoutes.PATCH("/:id", middleware.AuthorOrAdmin(cfg.Admin, repo.CanManage), func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		var dto ConfessionUpdateRequest
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			problem.InvalidBody(c, err)
 			return
 		}
-		if err := service.Delete(uint(id)); err != nil {
+		confession, err := service.Update(c.Request.Context(), id, dto)
+		if err != nil {
 			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+				problem.NotFound(c, "confession not found")
 				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete"})
+			problem.Internal(c, err, "failed to update")
+			return
+		}
+		c.JSON(http.StatusOK, confession)
+	})
+
+	confessionRoutes.GET("/:id/revisions", func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		offset, limit := pagination.Parse(c, cfg.Pagination.Confessions)
+		revisions, err := service.Revisions(c.Request.Context(), id, offset, limit)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "confession not found")
+				return
+			}
+			problem.Internal(c, err, "failed to fetch revisions")
+			return
+		}
+		respondList(c, Page{Offset: offset, Limit: limit}, revisions, "", func() (int64, error) {
+			return service.CountRevisions(c.Request.Context(), id)
+		})
+	})
+
+	confessionRoutes.DELETE("/:id", middleware.AuthorOrAdmin(cfg.Admin, repo.CanManage), func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		if err := service.Delete(c.Request.Context(), id); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "confession not found")
+				return
+			}
+			problem.Internal(c, err, "failed to delete")
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"message": "successfully deleted"})
@@ -98,66 +209,122 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB) {
 	confessionRoutes.GET("/language/:language", func(c *gin.Context) {
 		language := strings.TrimSpace(c.Param("language"))
 		if language == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "language required"})
+			problem.BadRequest(c, "language required")
 			return
 		}
-		offset, limit := parsePagination(c)
-		confessions, err := service.GetByLanguage(language, offset, limit)
+		page, ok := parsePage(c, cfg)
+		if !ok {
+			return
+		}
+		confessions, err := service.GetByLanguage(c.Request.Context(), language, page)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch"})
+			listError(c, err, "failed to fetch confessions")
 			return
 		}
-		c.JSON(http.StatusOK, confessions)
+		respondList(c, page, confessions, NextCursor(page, orderRecent, confessions), func() (int64, error) {
+			return service.CountByLanguage(c.Request.Context(), language)
+		})
 	})
 
 	confessionRoutes.GET("/top", func(c *gin.Context) {
-		offset, limit := parsePagination(c)
-		confessions, err := service.GetTopConfessions(offset, limit)
+		page, ok := parsePage(c, cfg)
+		if !ok {
+			return
+		}
+		confessions, err := service.GetTopConfessions(c.Request.Context(), page)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch"})
+			listError(c, err, "failed to fetch confessions")
 			return
 		}
-		c.JSON(http.StatusOK, confessions)
+		respondList(c, page, confessions, NextCursor(page, orderUpvotes, confessions), func() (int64, error) {
+			return service.CountTopConfessions(c.Request.Context())
+		})
 	})
 
 	confessionRoutes.GET("/trending/weekly", func(c *gin.Context) {
-		offset, limit := parsePagination(c)
-		confessions, err := service.TrendingWeekly(offset, limit)
+		page, ok := parsePage(c, cfg)
+		if !ok {
+			return
+		}
+		confessions, err := service.TrendingWeekly(c.Request.Context(), page)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed"})
+			listError(c, err, "failed to fetch confessions")
 			return
 		}
-		c.JSON(http.StatusOK, confessions)
+		respondList(c, page, confessions, NextCursor(page, service.trendingOrder(), confessions), func() (int64, error) {
+			return service.CountTrendingWeekly(c.Request.Context())
+		})
 	})
 
 	confessionRoutes.GET("/trending/monthly", func(c *gin.Context) {
-		offset, limit := parsePagination(c)
-		confessions, err := service.TrendingMonthly(offset, limit)
+		page, ok := parsePage(c, cfg)
+		if !ok {
+			return
+		}
+		confessions, err := service.TrendingMonthly(c.Request.Context(), page)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed"})
+			listError(c, err, "failed to fetch confessions")
 			return
 		}
-		c.JSON(http.StatusOK, confessions)
+		respondList(c, page, confessions, NextCursor(page, service.trendingOrder(), confessions), func() (int64, error) {
+			return service.CountTrendingMonthly(c.Request.Context())
+		})
+	})
+
+	confessionRoutes.GET("/hot", func(c *gin.Context) {
+		page, ok := parsePage(c, cfg)
+		if !ok {
+			return
+		}
+		confessions, err := service.Hot(c.Request.Context(), page)
+		if err != nil {
+			listError(c, err, "failed to fetch confessions")
+			return
+		}
+		respondList(c, page, confessions, NextCursor(page, orderHot, confessions), func() (int64, error) {
+			return service.CountHot(c.Request.Context())
+		})
 	})
 
 	confessionRoutes.GET("/hall-of-fame", func(c *gin.Context) {
-		offset, limit := parsePagination(c)
-		confessions, err := service.HallOfFame(offset, limit)
+		page, ok := parsePage(c, cfg)
+		if !ok {
+			return
+		}
+		confessions, err := service.HallOfFame(c.Request.Context(), page)
+		if err != nil {
+			listError(c, err, "failed to fetch confessions")
+			return
+		}
+		respondList(c, page, confessions, NextCursor(page, orderUpvotes, confessions), func() (int64, error) {
+			return service.CountHallOfFame(c.Request.Context())
+		})
+	})
+
+	// confessions still waiting for an accepted fix
+	confessionRoutes.GET("/unsolved", func(c *gin.Context) {
+		page, ok := parsePage(c, cfg)
+		if !ok {
+			return
+		}
+		confessions, err := service.Unsolved(c.Request.Context(), page)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed"})
+			listError(c, err, "failed to fetch confessions")
 			return
 		}
-		c.JSON(http.StatusOK, confessions)
+		respondList(c, page, confessions, NextCursor(page, orderRecent, confessions), func() (int64, error) {
+			return service.CountUnsolved(c.Request.Context())
+		})
 	})
 
 	confessionRoutes.GET("/random", func(c *gin.Context) {
-		cfs, err := service.Random()
+		cfs, err := service.Random(c.Request.Context())
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+				problem.NotFound(c, "confession not found")
 				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed"})
+			problem.Internal(c, err, "failed to fetch a random confession")
 			return
 		}
 		c.JSON(http.StatusOK, cfs)
@@ -169,17 +336,32 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB) {
 		language := strings.TrimSpace(c.Query("language"))
 		tag := strings.TrimSpace(c.Query("tag"))
 
-		if q == "" && language == "" && tag == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "query parameters required"})
+		var solved *bool
+		if raw := strings.TrimSpace(c.Query("solved")); raw != "" {
+			v, err := strconv.ParseBool(raw)
+			if err != nil {
+				problem.BadRequest(c, "solved must be true or false")
+				return
+			}
+			solved = &v
+		}
+
+		if q == "" && language == "" && tag == "" && solved == nil {
+			problem.BadRequest(c, "query parameters required")
 			return
 		}
 
-		offset, limit := parsePagination(c)
-		results, err := service.Search(q, language, tag, offset, limit)
+		page, ok := parsePage(c, cfg)
+		if !ok {
+			return
+		}
+		results, err := service.Search(c.Request.Context(), q, language, tag, solved, page)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed"})
+			listError(c, err, "failed to fetch confessions")
 			return
 		}
-		c.JSON(http.StatusOK, results)
+		respondList(c, page, results, NextSearchCursor(page, results), func() (int64, error) {
+			return service.CountSearch(c.Request.Context(), q, language, tag, solved)
+		})
 	})
 }
@@ -0,0 +1,201 @@
+package confession
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sort orders a cursor can resume; a cursor is only valid for the order it was issued for
+const (
+	orderRecent   = "recent"   // created_at DESC, id DESC
+	orderUpvotes  = "upvotes"  // upvotes DESC, id DESC
+	orderRank     = "rank"     // full-text rank DESC, id DESC
+	orderHot      = "hot"      // hot_score DESC, id DESC
+	orderReaction = "reaction" // count of one reaction kind DESC, id DESC
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of the last item of a page. It is handed to clients
+// as an opaque base64 string and resumes the listing right after that item,
+// so rows inserted meanwhile neither shift nor repeat items.
+type Cursor struct {
+	Order     string    `json:"o"`
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"c,omitzero"`
+	Upvotes   int       `json:"u,omitzero"`
+	Rank      float64   `json:"r,omitzero"`
+	HotScore  float64   `json:"h,omitzero"`
+	Kind      string    `json:"k,omitempty"` // reaction kind of an orderReaction cursor
+	Count     int       `json:"n,omitzero"`
+}
+
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	switch c.Order {
+	case orderRecent, orderUpvotes, orderRank, orderHot:
+		return &c, nil
+	case orderReaction:
+		if c.Kind != "" {
+			return &c, nil
+		}
+	}
+	return nil, ErrInvalidCursor
+}
+
+// Page selects a window of a listing: classic offset/limit, or keyset
+// pagination when Keyset is set (After is nil on the first page).
+// Reaction, when set, replaces the listing's own order by the count of that
+// reaction kind; ranked search keeps ordering by relevance.
+type Page struct {
+	Offset   int
+	Limit    int
+	Keyset   bool
+	After    *Cursor
+	Reaction string
+}
+
+// orderFor is the effective sort order of a listing that normally sorts by order
+func (p Page) orderFor(order string) string {
+	if p.Reaction != "" && order != orderRank {
+		return orderReaction
+	}
+	return order
+}
+
+// check rejects a cursor issued by a listing with a different sort order
+func (p Page) check(order string) error {
+	if p.After == nil {
+		return nil
+	}
+	order = p.orderFor(order)
+	if p.After.Order != order || (order == orderReaction && p.After.Kind != p.Reaction) {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// recent orders newest first and applies the page window
+func (p Page) recent(db *gorm.DB) *gorm.DB {
+	if p.Reaction != "" {
+		return p.byReaction(db)
+	}
+	if p.After != nil {
+		db = db.Where("(confessions.created_at < ? OR (confessions.created_at = ? AND confessions.id < ?))",
+			p.After.CreatedAt, p.After.CreatedAt, p.After.ID)
+	} else {
+		db = db.Offset(p.Offset)
+	}
+	return db.Order("confessions.created_at DESC, confessions.id DESC").Limit(p.Limit)
+}
+
+// byUpvotes orders most upvoted first and applies the page window
+func (p Page) byUpvotes(db *gorm.DB) *gorm.DB {
+	if p.Reaction != "" {
+		return p.byReaction(db)
+	}
+	if p.After != nil {
+		db = db.Where("(confessions.upvotes < ? OR (confessions.upvotes = ? AND confessions.id < ?))",
+			p.After.Upvotes, p.After.Upvotes, p.After.ID)
+	} else {
+		db = db.Offset(p.Offset)
+	}
+	return db.Order("confessions.upvotes DESC, confessions.id DESC").Limit(p.Limit)
+}
+
+// byHot orders by precomputed hot score and applies the page window
+func (p Page) byHot(db *gorm.DB) *gorm.DB {
+	if p.Reaction != "" {
+		return p.byReaction(db)
+	}
+	if p.After != nil {
+		db = db.Where("(confessions.hot_score < ? OR (confessions.hot_score = ? AND confessions.id < ?))",
+			p.After.HotScore, p.After.HotScore, p.After.ID)
+	} else {
+		db = db.Offset(p.Offset)
+	}
+	return db.Order("confessions.hot_score DESC, confessions.id DESC").Limit(p.Limit)
+}
+
+const reactionCountSQL = "COALESCE((SELECT rc.count FROM reaction_counts rc " +
+	"WHERE rc.confession_id = confessions.id AND rc.kind = ?), 0)"
+
+// byReaction orders by the count of p.Reaction and applies the page window
+func (p Page) byReaction(db *gorm.DB) *gorm.DB {
+	if p.After != nil {
+		db = db.Where("("+reactionCountSQL+" < ? OR ("+reactionCountSQL+" = ? AND confessions.id < ?))",
+			p.Reaction, p.After.Count, p.Reaction, p.After.Count, p.After.ID)
+	} else {
+		db = db.Offset(p.Offset)
+	}
+	return db.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:                reactionCountSQL + " DESC, confessions.id DESC",
+		Vars:               []any{p.Reaction},
+		WithoutParentheses: true,
+	}}).Limit(p.Limit)
+}
+
+// byRank orders a ranked search subquery (columns id, rank) and applies the page window
+func (p Page) byRank(db *gorm.DB) *gorm.DB {
+	if p.After != nil {
+		db = db.Where("(hits.rank < ? OR (hits.rank = ? AND hits.id < ?))", p.After.Rank, p.After.Rank, p.After.ID)
+	} else {
+		db = db.Offset(p.Offset)
+	}
+	return db.Order("hits.rank DESC, hits.id DESC").Limit(p.Limit)
+}
+
+// NextCursor returns the cursor resuming after the last confession of a full
+// page, or "" when the page was not full (no more items).
+func NextCursor(page Page, order string, items []Confession) string {
+	if !page.Keyset || len(items) == 0 || len(items) < page.Limit {
+		return ""
+	}
+	last := items[len(items)-1]
+	order = page.orderFor(order)
+	c := Cursor{Order: order, ID: last.ID}
+	switch order {
+	case orderRecent:
+		c.CreatedAt = last.CreatedAt
+	case orderUpvotes:
+		c.Upvotes = last.Upvotes
+	case orderHot:
+		c.HotScore = last.HotScore
+	case orderReaction:
+		c.Kind = page.Reaction
+		c.Count = last.Reactions.Count(page.Reaction)
+	}
+	return c.Encode()
+}
+
+// NextSearchCursor is NextCursor for search hits, which may be ranked
+func NextSearchCursor(page Page, hits []SearchHit) string {
+	if !page.Keyset || len(hits) == 0 || len(hits) < page.Limit {
+		return ""
+	}
+	last := hits[len(hits)-1]
+	if !last.ranked {
+		if page.Reaction != "" {
+			return Cursor{Order: orderReaction, ID: last.ID, Kind: page.Reaction, Count: last.Reactions.Count(page.Reaction)}.Encode()
+		}
+		return Cursor{Order: orderRecent, ID: last.ID, CreatedAt: last.CreatedAt}.Encode()
+	}
+	return Cursor{Order: orderRank, ID: last.ID, Rank: last.Rank}.Encode()
+}
@@ -6,5 +6,36 @@ type ConfessionRequest struct {
 	Snippet     string   `json:"snippet" binding:"omitempty"`
 	Language    string   `json:"language" binding:"required"`
 	Tags        []string `json:"tags" binding:"omitempty,dive,min=1"`
-	IsFlagged   bool     `json:"isFlagged"`
+}
+
+// ConfessionUpdateRequest is a partial update; nil fields are left untouched.
+type ConfessionUpdateRequest struct {
+	Title       *string   `json:"title" binding:"omitempty,min=5,max=100"`
+	Description *string   `json:"description" binding:"omitempty,min=10"`
+	Snippet     *string   `json:"snippet" binding:"omitempty"`
+	Language    *string   `json:"language" binding:"omitempty,min=1"`
+	Tags        *[]string `json:"tags" binding:"omitempty,dive,min=1"`
+}
+
+// CreateConfessionResponse is returned once on create; the manage token is never stored in plain text.
+type CreateConfessionResponse struct {
+	Confession
+	ManageToken string `json:"manageToken"`
+}
+
+// SearchHit is a confession matched by /confessions/search. Rank and Highlight
+// (HTML-escaped text with the matches wrapped in <mark>, safe to render as
+// HTML) are only set for full-text queries.
+type SearchHit struct {
+	Confession
+	Rank      float64 `json:"rank,omitempty"`
+	Highlight string  `json:"highlight,omitempty"`
+
+	ranked bool
+}
+
+// CursorPage is the response shape of a listing requested with ?cursor=
+type CursorPage[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
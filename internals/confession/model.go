@@ -6,6 +6,14 @@ import (
 	"github.com/Balaji01-4D/shit-happens/internals/tag"
 )
 
+// Moderation states; flagged confessions stay hidden from listings until reviewed
+const (
+	ModerationNone     = ""
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
 type Confession struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	Title       string    `gorm:"size:255;not null" json:"title"`
@@ -14,7 +22,29 @@ type Confession struct {
 	Snippet     string    `gorm:"type:text" json:"snippet"`
 	Tags        []tag.Tag `gorm:"many2many:confession_tags;" json:"tags"`
 	Sentiment   string    `gorm:"size:20" json:"sentiment"` // e.g., "positive", "negative", "neutral"
-	IsFlagged   bool      `gorm:"default:false" json:"isFlagged"`
+	IsFlagged   bool      `gorm:"default:false;index" json:"isFlagged"`
 	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
 	Upvotes     int       `gorm:"default:0" json:"upvotes"`
+	HotScore    float64   `gorm:"default:0;index" json:"hotScore"` // time-decayed votes, refreshed by the ranking job
+
+	ModerationStatus  string         `gorm:"size:20;default:''" json:"moderationStatus,omitempty"` // see Moderation* constants
+	ManageTokenHash   string         `gorm:"size:64" json:"-"`                                     // SHA-256 of the author's manage token
+	CommentCount      int            `gorm:"default:0" json:"commentCount"`                        // maintained by the comment package
+	Solved            bool           `gorm:"default:false;index" json:"solved"`                    // the author accepted a comment as the fix
+	AcceptedCommentID *uint          `json:"acceptedCommentId"`
+	Reactions         ReactionCounts `gorm:"foreignKey:ConfessionID" json:"reactions"` // per-kind counts, see the reaction package
+}
+
+// ConfessionRevision is a snapshot of a confession taken right before an edit,
+// so the full history can be replayed from the revisions plus the current row.
+type ConfessionRevision struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ConfessionID uint      `gorm:"index;not null" json:"confessionId"`
+	Title        string    `gorm:"size:255;not null" json:"title"`
+	Description  string    `gorm:"type:text" json:"description"`
+	Language     string    `gorm:"size:50" json:"language"`
+	Snippet      string    `gorm:"type:text" json:"snippet"`
+	Tags         []string  `gorm:"serializer:json" json:"tags"`
+	CreatedAt    time.Time `json:"createdAt"`
 }
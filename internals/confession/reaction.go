@@ -0,0 +1,33 @@
+package confession
+
+import "encoding/json"
+
+// ReactionCount is the number of reactions of one kind on a confession,
+// maintained by the reaction package
+type ReactionCount struct {
+	ConfessionID uint   `gorm:"primaryKey" json:"-"`
+	Kind         string `gorm:"primaryKey;size:20" json:"kind"`
+	Count        int    `gorm:"default:0" json:"count"`
+}
+
+// ReactionCounts renders as a {"kind": count} object
+type ReactionCounts []ReactionCount
+
+func (rc ReactionCounts) Count(kind string) int {
+	for _, r := range rc {
+		if r.Kind == kind {
+			return r.Count
+		}
+	}
+	return 0
+}
+
+func (rc ReactionCounts) MarshalJSON() ([]byte, error) {
+	m := make(map[string]int, len(rc))
+	for _, r := range rc {
+		if r.Count > 0 {
+			m[r.Kind] = r.Count
+		}
+	}
+	return json.Marshal(m)
+}
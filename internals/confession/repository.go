@@ -1,58 +1,98 @@
 package confession
 
 import (
+	"context"
+	"crypto/subtle"
 	"errors"
+	"sync"
 	"time"
 
+	"github.com/Balaji01-4D/shit-happens/internals/dialect"
 	"gorm.io/gorm"
 )
 
 type Repository struct {
 	DB *gorm.DB
+
+	searchOnce sync.Once
+	search     searchMode
+}
+
+// visible hides flagged confessions until a moderator has reviewed them
+func visible(db *gorm.DB) *gorm.DB {
+	return db.Where("confessions.is_flagged = ?", false)
 }
 
-func (r *Repository) GetTopConfessions(offset int, limit int) ([]Confession, error) { // renamed param
+func (r *Repository) GetTopConfessions(ctx context.Context, page Page) ([]Confession, error) {
 	var confessions []Confession
+	if err := page.check(orderUpvotes); err != nil {
+		return nil, err
+	}
 
-	err := r.DB.
-		Preload("Tags").
-		Offset(offset).
-		Limit(limit).
-		Order("upvotes DESC").
+	err := r.DB.WithContext(ctx).
+		Scopes(visible, page.byUpvotes).
+		Preload("Tags").Preload("Reactions").
 		Find(&confessions).Error
 
 	return confessions, err
 }
 
 // GetTopConfessionsSince returns top confessions since a given time (weekly/monthly trending)
-func (r *Repository) GetTopConfessionsSince(since time.Time, offset int, limit int) ([]Confession, error) {
+func (r *Repository) GetTopConfessionsSince(ctx context.Context, since time.Time, page Page) ([]Confession, error) {
 	var confessions []Confession
-	err := r.DB.
-		Preload("Tags").
-		Where("created_at >= ?", since).
-		Offset(offset).
-		Limit(limit).
-		Order("upvotes DESC").
+	if err := page.check(orderUpvotes); err != nil {
+		return nil, err
+	}
+	err := r.DB.WithContext(ctx).
+		Scopes(visible, createdSince(since), page.byUpvotes).
+		Preload("Tags").Preload("Reactions").
+		Find(&confessions).Error
+	return confessions, err
+}
+
+// Hot returns confessions by precomputed hot score
+func (r *Repository) Hot(ctx context.Context, page Page) ([]Confession, error) {
+	var confessions []Confession
+	if err := page.check(orderHot); err != nil {
+		return nil, err
+	}
+	err := r.DB.WithContext(ctx).
+		Scopes(visible, page.byHot).
+		Preload("Tags").Preload("Reactions").
+		Find(&confessions).Error
+	return confessions, err
+}
+
+// HotSince returns confessions created since a given time by hot score (hot trending)
+func (r *Repository) HotSince(ctx context.Context, since time.Time, page Page) ([]Confession, error) {
+	var confessions []Confession
+	if err := page.check(orderHot); err != nil {
+		return nil, err
+	}
+	err := r.DB.WithContext(ctx).
+		Scopes(visible, createdSince(since), page.byHot).
+		Preload("Tags").Preload("Reactions").
 		Find(&confessions).Error
 	return confessions, err
 }
 
 // HallOfFame returns all‑time top confessions (larger limit by caller) - could add thresholds later
-func (r *Repository) HallOfFame(offset, limit int) ([]Confession, error) {
+func (r *Repository) HallOfFame(ctx context.Context, page Page) ([]Confession, error) {
 	var confessions []Confession
-	err := r.DB.
-		Preload("Tags").
-		Offset(offset).
-		Limit(limit).
-		Order("upvotes DESC").
+	if err := page.check(orderUpvotes); err != nil {
+		return nil, err
+	}
+	err := r.DB.WithContext(ctx).
+		Scopes(visible, page.byUpvotes).
+		Preload("Tags").Preload("Reactions").
 		Find(&confessions).Error
 	return confessions, err
 }
 
-// RandomConfession returns a single random confession (PostgreSQL RANDOM())
-func (r *Repository) RandomConfession() (Confession, error) {
+// RandomConfession returns a single random confession (RANDOM() on both dialects)
+func (r *Repository) RandomConfession(ctx context.Context) (Confession, error) {
 	var c Confession
-	err := r.DB.Preload("Tags").Order("RANDOM()").Limit(1).First(&c).Error
+	err := r.DB.WithContext(ctx).Scopes(visible).Preload("Tags").Preload("Reactions").Order("RANDOM()").Limit(1).First(&c).Error
 	return c, err
 }
 
@@ -60,39 +100,114 @@ func NewRepo(db *gorm.DB) *Repository {
 	return &Repository{DB: db}
 }
 
-func (r *Repository) Create(confession *Confession) error {
-	return r.DB.Create(confession).Error
+func (r *Repository) Create(ctx context.Context, confession *Confession) error {
+	tx := r.DB.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	if err := tx.Create(confession).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := r.reindex(tx, confession.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
 }
 
-func (r *Repository) List(offset, limit int) ([]Confession, error) {
+func (r *Repository) List(ctx context.Context, page Page, sentiment string) ([]Confession, error) {
 	var out []Confession
+	if err := page.check(orderRecent); err != nil {
+		return nil, err
+	}
 
-	err := r.DB.
-		Preload("Tags").
-		Offset(offset).
-		Limit(limit).
-		Order("created_at desc").
+	err := r.DB.WithContext(ctx).
+		Scopes(visible, withSentiment(sentiment), page.recent).
+		Preload("Tags").Preload("Reactions").
 		Find(&out).Error
 
 	return out, err
 }
 
-func (r *Repository) Get(id uint) (Confession, error) {
+// RescoreSentiment walks all confessions in batches and stores the label
+// computed by analyze wherever it differs from the current one
+func (r *Repository) RescoreSentiment(ctx context.Context, batchSize int, analyze func(Confession) string) (int, error) {
+	var batch []Confession
+	updated := 0
+
+	res := r.DB.WithContext(ctx).
+		Select("id", "title", "description", "sentiment").
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			for _, c := range batch {
+				label := analyze(c)
+				if label == c.Sentiment {
+					continue
+				}
+				if err := r.DB.WithContext(ctx).Model(&Confession{}).
+					Where("id = ?", c.ID).
+					UpdateColumn("sentiment", label).Error; err != nil {
+					return err
+				}
+				updated++
+			}
+			return nil
+		})
+
+	return updated, res.Error
+}
+
+func (r *Repository) Get(ctx context.Context, id uint) (Confession, error) {
 	var confession Confession
 
-	err := r.DB.Preload("Tags").First(&confession, id).Error
+	err := r.DB.WithContext(ctx).Preload("Tags").Preload("Reactions").First(&confession, id).Error
 
 	return confession, err
 }
 
-func (r *Repository) Delete(id uint) error {
-	tx := r.DB.Begin()
+// CanManage reports whether token is the manage token issued for the
+// confession; it is compared by hash, in constant time
+func (r *Repository) CanManage(ctx context.Context, id uint, token string) bool {
+	if token == "" {
+		return false
+	}
+	var hashes []string
+	if err := r.DB.WithContext(ctx).Model(&Confession{}).Where("id = ?", id).Pluck("manage_token_hash", &hashes).Error; err != nil || len(hashes) == 0 || hashes[0] == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashes[0]), []byte(hashToken(token))) == 1
+}
+
+func (r *Repository) Delete(ctx context.Context, id uint) error {
+	tx := r.DB.WithContext(ctx).Begin()
 	if err := tx.Error; err != nil {
 		return err
 	}
 
-	// Removing join rows first (ignore if none)
-	if err := tx.Exec("DELETE FROM confession_tags WHERE confession_id = ?", id).Error; err != nil {
+	// Removing join rows and everything posted on the confession first (ignore if none)
+	for _, stmt := range []string{
+		"DELETE FROM confession_tags WHERE confession_id = ?",
+		"DELETE FROM comment_upvotes WHERE comment_id IN (SELECT id FROM comments WHERE confession_id = ?)",
+		"DELETE FROM comments WHERE confession_id = ?",
+		"DELETE FROM reactions WHERE confession_id = ?",
+		"DELETE FROM reports WHERE confession_id = ?",
+		"DELETE FROM upvotes WHERE confession_id = ?",
+	} {
+		if err := tx.Exec(stmt, id).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Where("confession_id = ?", id).Delete(&ConfessionRevision{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Where("confession_id = ?", id).Delete(&ReactionCount{}).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
@@ -107,49 +222,225 @@ func (r *Repository) Delete(id uint) error {
 		return gorm.ErrRecordNotFound
 	}
 
+	if err := r.unindex(tx, id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	return tx.Commit().Error
 }
 
-func (r *Repository) GetByLanguage(language string, offset int, limit int) ([]Confession, error) {
-	var confessions []Confession
-	err := r.DB.
-		Preload("Tags").
+// Update stores the revision snapshot and the edited confession in one transaction.
+// Tags are only replaced when replaceTags is set, so a partial edit keeps them.
+func (r *Repository) Update(ctx context.Context, confession *Confession, revision *ConfessionRevision, replaceTags bool) error {
+	tx := r.DB.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	if err := tx.Create(revision).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Model(confession).
+		Select("title", "description", "language", "snippet", "sentiment", "updated_at").
+		Updates(confession).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if replaceTags {
+		if err := tx.Model(confession).Association("Tags").Replace(confession.Tags); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := r.reindex(tx, confession.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// ListRevisions returns the edit history of a confession, newest first
+func (r *Repository) ListRevisions(ctx context.Context, confessionID uint, offset, limit int) ([]ConfessionRevision, error) {
+	var revisions []ConfessionRevision
+	err := r.DB.WithContext(ctx).
+		Where("confession_id = ?", confessionID).
 		Offset(offset).
 		Limit(limit).
-		Where("language ILIKE ?", language).
-		Order("created_at DESC").
+		Order("created_at DESC, id DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// CountRevisions is the total behind ListRevisions
+func (r *Repository) CountRevisions(ctx context.Context, confessionID uint) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&ConfessionRevision{}).Where("confession_id = ?", confessionID).Count(&n).Error
+	return n, err
+}
+
+// Unsolved lists confessions still waiting for an accepted fix, newest first
+func (r *Repository) Unsolved(ctx context.Context, page Page) ([]Confession, error) {
+	var confessions []Confession
+	if err := page.check(orderRecent); err != nil {
+		return nil, err
+	}
+	err := r.DB.WithContext(ctx).
+		Scopes(visible, unsolved, page.recent).
+		Preload("Tags").Preload("Reactions").
 		Find(&confessions).Error
 
 	return confessions, err
 }
 
-func (r *Repository) Search(q, language, tag string, offset, limit int) ([]Confession, error) {
+func (r *Repository) GetByLanguage(ctx context.Context, language string, page Page) ([]Confession, error) {
 	var confessions []Confession
-	db := r.DB.Model(&Confession{}).Preload("Tags")
+	if err := page.check(orderRecent); err != nil {
+		return nil, err
+	}
+	err := r.DB.WithContext(ctx).
+		Scopes(visible, withLanguage(language), page.recent).
+		Preload("Tags").Preload("Reactions").
+		Find(&confessions).Error
 
-	if tag != "" {
+	return confessions, err
+}
 
-		db = db.Joins("JOIN confession_tags ct ON ct.confession_id = confessions.id").
-			Joins("JOIN tags ON tags.id = ct.tag_id").
-			Where("tags.name ILIKE ?", tag)
+// Search filters by language / tag / solved state and, when q is given, ranks
+// matches with the full-text index. Without an index it falls back to pattern matching.
+func (r *Repository) Search(ctx context.Context, q, language, tag string, solved *bool, page Page) ([]SearchHit, error) {
+	if mode := r.searchMode(); q != "" && mode != searchLike {
+		return r.rankedSearch(ctx, mode, q, language, tag, solved, page)
 	}
+	if err := page.check(orderRecent); err != nil {
+		return nil, err
+	}
+
+	var confessions []Confession
+	db := r.DB.WithContext(ctx).Model(&Confession{}).Scopes(visible, r.matching(q, language, tag), withSolved(solved), page.recent).Preload("Tags").Preload("Reactions")
+
+	err := db.Find(&confessions).Error
 
-	if language != "" {
-		db = db.Where("language ILIKE ?", language)
+	hits := make([]SearchHit, 0, len(confessions))
+	for _, c := range confessions {
+		hits = append(hits, SearchHit{Confession: c})
 	}
+	return hits, err
+}
+
+// matching is the pattern-matching search filter used without a full-text index
+func (r *Repository) matching(q, language, tag string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = withLanguage(language)(r.withTag(tag)(db))
 
-	if q != "" {
-		like := "%" + q + "%"
-		db = db.Where("(title ILIKE ? OR description ILIKE ? OR snippet ILIKE ?)", like, like, like)
+		if q != "" {
+			like := "%" + q + "%"
+			op := dialect.ILike(db)
+			db = db.Where("(title "+op+" ? OR description "+op+" ? OR snippet "+op+" ?)", like, like, like)
+		}
+		return db
 	}
+}
 
-	err := db.Distinct().
-		Offset(offset).
-		Limit(limit).
-		Order("created_at DESC").
-		Find(&confessions).Error
-	return confessions, err
+func withSentiment(sentiment string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if sentiment == "" {
+			return db
+		}
+		return db.Where("sentiment = ?", sentiment)
+	}
+}
+
+func withSolved(solved *bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if solved == nil {
+			return db
+		}
+		return db.Where("confessions.solved = ?", *solved)
+	}
+}
+
+func unsolved(db *gorm.DB) *gorm.DB {
+	return db.Where("confessions.solved = ?", false)
 }
 
+func withLanguage(language string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if language == "" {
+			return db
+		}
+		return db.Where("confessions.language "+dialect.ILike(db)+" ?", language)
+	}
+}
+
+// withTag keeps the confessions carrying the tag, matched case-insensitively
+func (r *Repository) withTag(tag string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if tag == "" {
+			return db
+		}
+		return db.Where("confessions.id IN (?)", r.DB.Table("confession_tags ct").
+			Select("ct.confession_id").
+			Joins("JOIN tags ON tags.id = ct.tag_id").
+			Where("tags.name "+dialect.ILike(db)+" ?", tag))
+	}
+}
+
+func createdSince(since time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("created_at >= ?", since)
+	}
+}
+
+// count runs the filters of a listing as a single COUNT(*), without preloads or ordering
+func (r *Repository) count(ctx context.Context, filters ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&Confession{}).Scopes(visible).Scopes(filters...).Count(&n).Error
+	return n, err
+}
+
+// CountList is the total behind List
+func (r *Repository) CountList(ctx context.Context, sentiment string) (int64, error) {
+	return r.count(ctx, withSentiment(sentiment))
+}
+
+// CountByLanguage is the total behind GetByLanguage
+func (r *Repository) CountByLanguage(ctx context.Context, language string) (int64, error) {
+	return r.count(ctx, withLanguage(language))
+}
+
+// CountVisible is the total behind GetTopConfessions, Hot and HallOfFame
+func (r *Repository) CountVisible(ctx context.Context) (int64, error) {
+	return r.count(ctx)
+}
+
+// CountSince is the total behind GetTopConfessionsSince and HotSince
+func (r *Repository) CountSince(ctx context.Context, since time.Time) (int64, error) {
+	return r.count(ctx, createdSince(since))
+}
+
+// CountUnsolved is the total behind Unsolved
+func (r *Repository) CountUnsolved(ctx context.Context) (int64, error) {
+	return r.count(ctx, unsolved)
+}
+
+// CountSearch is the total behind Search
+func (r *Repository) CountSearch(ctx context.Context, q, language, tag string, solved *bool) (int64, error) {
+	if mode := r.searchMode(); q != "" && mode != searchLike {
+		var n int64
+		matches, ok := r.rankedMatches(mode, q, language, tag, solved)
+		if !ok {
+			return 0, nil
+		}
+		err := r.DB.WithContext(ctx).Table("(?) AS hits", matches).Count(&n).Error
+		return n, err
+	}
+	return r.count(ctx, r.matching(q, language, tag), withSolved(solved))
+}
 
 func isNotFound(err error) bool { return errors.Is(err, gorm.ErrRecordNotFound) }
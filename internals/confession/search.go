@@ -0,0 +1,226 @@
+package confession
+
+import (
+	"context"
+	"errors"
+	"html"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrSearchIndexUnsupported is returned by EnsureSearchIndex when the database
+// has no full-text index: SQLite built without the sqlite_fts5 tag, or
+// PostgreSQL before `migrate up`. Search then falls back to plain pattern matching.
+var ErrSearchIndexUnsupported = errors.New("full-text search index not supported by this database")
+
+type searchMode int
+
+const (
+	searchLike searchMode = iota
+	searchPostgres
+	searchFTS5
+)
+
+const ftsTable = "confessions_fts"
+
+// the weighted document (title > description > snippet) is defined once, as a
+// SQL function in migrations/postgres/0002_confession_search
+const pgSearchDocument = "confession_search_document(title, description, snippet)"
+
+// the index marks matches with these private-use runes; they become <mark>
+// only after the text around them is HTML-escaped, see safeHighlight
+const (
+	markStart = "\uE000"
+	markStop  = "\uE001"
+)
+
+var highlightMarks = strings.NewReplacer(markStart, "<mark>", markStop, "</mark>")
+
+const pgHeadlineOptions = "StartSel=" + markStart + ", StopSel=" + markStop + ", MaxFragments=2, MaxWords=24, MinWords=8"
+
+// EnsureSearchIndex creates and backfills the FTS5 virtual table on SQLite.
+// On PostgreSQL the tsvector column and its GIN index come with the
+// migrations; it only reports whether they are there.
+func EnsureSearchIndex(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "postgres":
+		if !db.Migrator().HasColumn(&Confession{}, "search_vector") {
+			return ErrSearchIndexUnsupported
+		}
+		return nil
+	case "sqlite":
+		err := db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS " + ftsTable +
+			" USING fts5(title, description, snippet, tokenize = 'porter unicode61')").Error
+		if err != nil {
+			if strings.Contains(err.Error(), "no such module") {
+				return ErrSearchIndexUnsupported
+			}
+			return err
+		}
+		return db.Exec("INSERT INTO " + ftsTable + " (rowid, title, description, snippet) " +
+			"SELECT id, title, description, snippet FROM confessions WHERE id NOT IN (SELECT rowid FROM " + ftsTable + ")").Error
+	default:
+		return ErrSearchIndexUnsupported
+	}
+}
+
+// searchMode detects once which index EnsureSearchIndex left behind
+func (r *Repository) searchMode() searchMode {
+	r.searchOnce.Do(func() {
+		switch r.DB.Dialector.Name() {
+		case "postgres":
+			if r.DB.Migrator().HasColumn(&Confession{}, "search_vector") {
+				r.search = searchPostgres
+			}
+		case "sqlite":
+			if r.DB.Migrator().HasTable(ftsTable) {
+				r.search = searchFTS5
+			}
+		}
+	})
+	return r.search
+}
+
+// reindex refreshes the full-text entry of one confession inside tx
+func (r *Repository) reindex(tx *gorm.DB, id uint) error {
+	switch r.searchMode() {
+	case searchPostgres:
+		return tx.Exec("UPDATE confessions SET search_vector = "+pgSearchDocument+" WHERE id = ?", id).Error
+	case searchFTS5:
+		if err := r.unindex(tx, id); err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO "+ftsTable+" (rowid, title, description, snippet) "+
+			"SELECT id, title, description, snippet FROM confessions WHERE id = ?", id).Error
+	}
+	return nil
+}
+
+// unindex drops the full-text entry of a deleted confession (the tsvector column goes with the row)
+func (r *Repository) unindex(tx *gorm.DB, id uint) error {
+	if r.searchMode() == searchFTS5 {
+		return tx.Exec("DELETE FROM "+ftsTable+" WHERE rowid = ?", id).Error
+	}
+	return nil
+}
+
+// rankedMatches selects (id, rank, highlight) of every visible match; ok is
+// false when the query has no searchable terms
+func (r *Repository) rankedMatches(mode searchMode, q, language, tag string, solved *bool) (db *gorm.DB, ok bool) {
+	switch mode {
+	case searchPostgres:
+		db = r.DB.Table("confessions, websearch_to_tsquery('english', ?) AS query", q).
+			Select("confessions.id, ts_rank(confessions.search_vector, query) AS rank, "+
+				"ts_headline('english', coalesce(confessions.title, '') || ' ' || coalesce(confessions.description, ''), query, ?) AS highlight",
+				pgHeadlineOptions).
+			Where("confessions.search_vector @@ query")
+	case searchFTS5:
+		match := ftsQuery(q)
+		if match == "" {
+			return nil, false
+		}
+		// bm25 is lower-is-better; negate it so both dialects sort rank DESC
+		db = r.DB.Table(ftsTable).
+			Select("confessions.id, -bm25("+ftsTable+", 10.0, 5.0, 1.0) AS rank, "+
+				"snippet("+ftsTable+", -1, '"+markStart+"', '"+markStop+"', '…', 24) AS highlight").
+			Joins("JOIN confessions ON confessions.id = "+ftsTable+".rowid").
+			Where(ftsTable+" MATCH ?", match)
+	default:
+		return nil, false
+	}
+
+	// the same filters as the pattern-matching fallback, so q only adds ranking
+	return db.Scopes(visible, withSolved(solved), r.withTag(tag), withLanguage(language)), true
+}
+
+type searchRow struct {
+	ID        uint
+	Rank      float64
+	Highlight string
+}
+
+// rankedSearch runs the free-text query against the full-text index and
+// returns hits ordered by relevance, newest first on ties
+func (r *Repository) rankedSearch(ctx context.Context, mode searchMode, q, language, tag string, solved *bool, page Page) ([]SearchHit, error) {
+	if err := page.check(orderRank); err != nil {
+		return nil, err
+	}
+
+	matches, ok := r.rankedMatches(mode, q, language, tag, solved)
+	if !ok {
+		return []SearchHit{}, nil
+	}
+
+	// rank is a computed column; wrapping the match lets the keyset filter on it
+	var rows []searchRow
+	if err := r.DB.WithContext(ctx).Table("(?) AS hits", matches).
+		Select("hits.id, hits.rank, hits.highlight").
+		Scopes(page.byRank).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []SearchHit{}, nil
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	var confessions []Confession
+	if err := r.DB.WithContext(ctx).Preload("Tags").Preload("Reactions").Where("id IN ?", ids).Find(&confessions).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]Confession, len(confessions))
+	for _, c := range confessions {
+		byID[c.ID] = c
+	}
+
+	hits := make([]SearchHit, 0, len(rows))
+	for _, row := range rows {
+		if c, ok := byID[row.ID]; ok {
+			hits = append(hits, SearchHit{Confession: c, Rank: row.Rank, Highlight: safeHighlight(row.Highlight), ranked: true})
+		}
+	}
+	return hits, nil
+}
+
+// safeHighlight escapes the user's text so the highlight can be rendered as
+// HTML, with <mark> around the matches being the only markup
+func safeHighlight(s string) string {
+	return highlightMarks.Replace(html.EscapeString(s))
+}
+
+// ftsQuery turns user input into a safe FTS5 expression, roughly matching
+// websearch_to_tsquery: words are ANDed, "or" alternates, -word excludes.
+func ftsQuery(q string) string {
+	var terms, excluded []string
+	for _, tok := range strings.Fields(q) {
+		switch {
+		case strings.EqualFold(tok, "or"):
+			if len(terms) > 0 && terms[len(terms)-1] != "OR" {
+				terms = append(terms, "OR")
+			}
+		case strings.HasPrefix(tok, "-") && len(tok) > 1:
+			excluded = append(excluded, quoteFTS(tok[1:]))
+		default:
+			terms = append(terms, quoteFTS(tok))
+		}
+	}
+	if len(terms) > 0 && terms[len(terms)-1] == "OR" {
+		terms = terms[:len(terms)-1]
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	expr := strings.Join(terms, " ")
+	for _, ex := range excluded {
+		expr += " NOT " + ex
+	}
+	return expr
+}
+
+func quoteFTS(term string) string {
+	return `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+}
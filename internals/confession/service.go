@@ -1,42 +1,165 @@
 package confession
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"strings"
 	"time"
 
+	"github.com/Balaji01-4D/shit-happens/internals/events"
+	"github.com/Balaji01-4D/shit-happens/internals/metrics"
+	"github.com/Balaji01-4D/shit-happens/internals/ranking"
+	"github.com/Balaji01-4D/shit-happens/internals/sentiment"
 	"github.com/Balaji01-4D/shit-happens/internals/tag"
+	"github.com/Balaji01-4D/shit-happens/internals/tracing"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type Service struct {
-	repo *Repository
+	repo        *Repository
+	analyzer    sentiment.Analyzer
+	hotTrending bool
+	gravity     float64
+	publisher   events.Publisher
 }
 
-func NewService(r *Repository) *Service {
-	return &Service{repo: r}
+// Options tune a Service; the zero value orders trending by raw upvotes,
+// seeds hot scores with ranking.DefaultGravity and publishes nothing
+type Options struct {
+	// HotTrending makes the trending listings order by hot score instead of raw upvotes
+	HotTrending bool
+
+	// Gravity seeds the hot score of new confessions, see ranking.Weight
+	Gravity float64
+
+	// Publisher, when set, is told about created and deleted confessions
+	Publisher events.Publisher
+}
+
+func NewService(r *Repository, analyzer sentiment.Analyzer, opts Options) *Service {
+	if opts.Gravity <= 0 {
+		opts.Gravity = ranking.DefaultGravity
+	}
+	return &Service{repo: r, analyzer: analyzer, hotTrending: opts.HotTrending, gravity: opts.Gravity, publisher: opts.Publisher}
 }
 
-// used to create the confessions from the dto and save to database
-func (s *Service) Create(dto ConfessionRequest) (Confession, error) {
+// used to create the confessions from the dto and save to database.
+// The returned manage token is only available here; just its hash is persisted.
+func (s *Service) Create(ctx context.Context, dto ConfessionRequest) (Confession, string, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.Create")
+	defer span.End()
+	token, err := newManageToken()
+	if err != nil {
+		return Confession{}, "", err
+	}
 
+	created := now()
 	confession := Confession{
 		Title:       dto.Title,
 		Description: dto.Description,
 		Language:    dto.Language,
 		Snippet:     dto.Snippet,
-		Sentiment:   "happy", // hardcoded for just now
-		IsFlagged:   dto.IsFlagged,
-		CreatedAt:   now(),
+		Sentiment:   s.analyzer.Analyze(sentimentText(dto.Title, dto.Description)),
+		IsFlagged:   false,
+		CreatedAt:   created,
 		Upvotes:     0,
+		// ranked from the start instead of waiting for the next refresh
+		HotScore: ranking.Weight(created, created, s.gravity),
+
+		ManageTokenHash: hashToken(token),
+	}
+
+	tags, err := s.resolveTags(ctx, dto.Tags)
+	if err != nil {
+		return Confession{}, "", err
+	}
+	confession.Tags = tags
+
+	if err := s.repo.Create(ctx, &confession); err != nil {
+		return Confession{}, "", err
+	}
+	metrics.ConfessionsCreated.Inc()
+	// the event carries the public confession only, never the manage token
+	s.publish(events.ConfessionCreated, confession, confession)
+	return confession, token, nil
+}
+
+// Update applies a partial edit and keeps the previous version as a revision
+func (s *Service) Update(ctx context.Context, id uint, dto ConfessionUpdateRequest) (Confession, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.Update")
+	defer span.End()
+	confession, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Confession{}, err
+	}
+
+	revision := ConfessionRevision{
+		ConfessionID: confession.ID,
+		Title:        confession.Title,
+		Description:  confession.Description,
+		Language:     confession.Language,
+		Snippet:      confession.Snippet,
+		Tags:         tagNames(confession.Tags),
+		CreatedAt:    now(),
+	}
+
+	if dto.Title != nil {
+		confession.Title = *dto.Title
+	}
+	if dto.Description != nil {
+		confession.Description = *dto.Description
 	}
+	if dto.Snippet != nil {
+		confession.Snippet = *dto.Snippet
+	}
+	if dto.Language != nil {
+		confession.Language = *dto.Language
+	}
+	if dto.Tags != nil {
+		tags, err := s.resolveTags(ctx, *dto.Tags)
+		if err != nil {
+			return Confession{}, err
+		}
+		confession.Tags = tags
+	}
+	if dto.Title != nil || dto.Description != nil {
+		confession.Sentiment = s.analyzer.Analyze(sentimentText(confession.Title, confession.Description))
+	}
+	confession.UpdatedAt = revision.CreatedAt
+
+	if err := s.repo.Update(ctx, &confession, &revision, dto.Tags != nil); err != nil {
+		return Confession{}, err
+	}
+	return confession, nil
+}
+
+// Revisions lists the edit history of an existing confession
+func (s *Service) Revisions(ctx context.Context, id uint, offset, limit int) ([]ConfessionRevision, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.Revisions")
+	defer span.End()
+	if _, err := s.repo.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRevisions(ctx, id, offset, limit)
+}
+
+func (s *Service) CountRevisions(ctx context.Context, id uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.CountRevisions")
+	defer span.End()
+	return s.repo.CountRevisions(ctx, id)
+}
 
+// resolveTags normalises the names and finds or creates the matching tags
+func (s *Service) resolveTags(ctx context.Context, names []string) ([]tag.Tag, error) {
 	var tags []tag.Tag
 
 	// Deduplicate incoming tags
-	seen := make(map[string]struct{}, len(dto.Tags))
-	for _, tagName := range dto.Tags {
+	seen := make(map[string]struct{}, len(names))
+	for _, tagName := range names {
 		tagName = strings.TrimSpace(strings.ToLower(tagName))
 		if tagName == "" {
 			continue
@@ -50,76 +173,228 @@ func (s *Service) Create(dto ConfessionRequest) (Confession, error) {
 	for tagName := range seen {
 		var t tag.Tag
 		// Try to find existing
-		if err := s.repo.DB.Where("name = ?", tagName).First(&t).Error; err != nil {
+		if err := s.repo.DB.WithContext(ctx).Where("name = ?", tagName).First(&t).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				// Create if absent, ignoring conflict (atomic)
-				if err := s.repo.DB.Clauses(clause.OnConflict{
+				if err := s.repo.DB.WithContext(ctx).Clauses(clause.OnConflict{
 					Columns:   []clause.Column{{Name: "name"}},
 					DoNothing: true,
 				}).Create(&tag.Tag{Name: tagName}).Error; err != nil {
-					return Confession{}, err
+					return nil, err
 				}
 				// Fetch the row (handles both created and conflicted cases)
-				if err := s.repo.DB.Where("name = ?", tagName).First(&t).Error; err != nil {
-					return Confession{}, err
+				if err := s.repo.DB.WithContext(ctx).Where("name = ?", tagName).First(&t).Error; err != nil {
+					return nil, err
 				}
 			} else {
-				return Confession{}, err
+				return nil, err
 			}
 		}
 		tags = append(tags, t)
 	}
-	confession.Tags = tags
+	return tags, nil
+}
 
-	err := s.repo.Create(&confession)
-	return confession, err
+// newManageToken returns 32 random bytes, hex-encoded; only its hash is stored
+func newManageToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
 }
 
-// list confessions based on the offset and limit
-func (s *Service) List(offset, limit int) ([]Confession, error) {
-	return s.repo.List(offset, limit)
+func sentimentText(title, description string) string {
+	return title + "\n" + description
+}
+
+func tagNames(tags []tag.Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// list confessions based on the offset and limit, optionally only one sentiment
+func (s *Service) List(ctx context.Context, page Page, sentiment string) ([]Confession, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.List")
+	defer span.End()
+	return s.repo.List(ctx, page, sentiment)
+}
+
+// RescoreSentiment re-runs the analyzer over every stored confession and
+// returns how many rows changed label
+func (s *Service) RescoreSentiment(ctx context.Context, batchSize int) (int, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.RescoreSentiment")
+	defer span.End()
+	return s.repo.RescoreSentiment(ctx, batchSize, func(c Confession) string {
+		return s.analyzer.Analyze(sentimentText(c.Title, c.Description))
+	})
 }
 
 // get confession by its id (primary key)
-func (s *Service) Get(id uint) (Confession, error) {
-	return s.repo.Get(id)
+func (s *Service) Get(ctx context.Context, id uint) (Confession, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.Get")
+	defer span.End()
+	return s.repo.Get(ctx, id)
 }
 
 // Delete the confession by its id
-func (s *Service) Delete(id uint) error {
-	return s.repo.Delete(id)
+func (s *Service) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "confession.Service.Delete")
+	defer span.End()
+	// loaded first so stream filters can still match the deleted confession
+	deleted, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.publish(events.ConfessionDeleted, deleted, map[string]any{"id": id})
+	return nil
+}
+
+func (s *Service) publish(kind string, about Confession, data any) {
+	if s.publisher == nil {
+		return
+	}
+	s.publisher.Publish(events.Event{Type: kind, Language: about.Language, Tags: tagNames(about.Tags), Data: data})
 }
 
 // Return the confessions based on the language
-func (s *Service) GetByLanguage(language string, offset int, limit int) ([]Confession, error) {
-	return s.repo.GetByLanguage(language, offset, limit)
+func (s *Service) GetByLanguage(ctx context.Context, language string, page Page) ([]Confession, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.GetByLanguage")
+	defer span.End()
+	return s.repo.GetByLanguage(ctx, language, page)
+}
+
+func (s *Service) GetTopConfessions(ctx context.Context, page Page) ([]Confession, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.GetTopConfessions")
+	defer span.End()
+	return s.repo.GetTopConfessions(ctx, page)
+}
+
+func (s *Service) TrendingWeekly(ctx context.Context, page Page) ([]Confession, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.TrendingWeekly")
+	defer span.End()
+	return s.trendingSince(ctx, now().AddDate(0, 0, -7), page)
+}
+
+func (s *Service) TrendingMonthly(ctx context.Context, page Page) ([]Confession, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.TrendingMonthly")
+	defer span.End()
+	return s.trendingSince(ctx, now().AddDate(0, -1, 0), page)
+}
+
+func (s *Service) trendingSince(ctx context.Context, since time.Time, page Page) ([]Confession, error) {
+	if s.hotTrending {
+		return s.repo.HotSince(ctx, since, page)
+	}
+	return s.repo.GetTopConfessionsSince(ctx, since, page)
+}
+
+// trendingOrder is the sort order (and cursor kind) of the trending listings
+func (s *Service) trendingOrder() string {
+	if s.hotTrending {
+		return orderHot
+	}
+	return orderUpvotes
+}
+
+// Hot lists confessions by time-decayed votes, see the ranking package
+func (s *Service) Hot(ctx context.Context, page Page) ([]Confession, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.Hot")
+	defer span.End()
+	return s.repo.Hot(ctx, page)
+}
+
+func (s *Service) HallOfFame(ctx context.Context, page Page) ([]Confession, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.HallOfFame")
+	defer span.End()
+	return s.repo.HallOfFame(ctx, page)
+}
+
+// Unsolved lists confessions without an accepted fix, newest first
+func (s *Service) Unsolved(ctx context.Context, page Page) ([]Confession, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.Unsolved")
+	defer span.End()
+	return s.repo.Unsolved(ctx, page)
+}
+
+// Count* return the totals behind the listings above, for paginated envelopes
+
+func (s *Service) CountList(ctx context.Context, sentiment string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.CountList")
+	defer span.End()
+	return s.repo.CountList(ctx, sentiment)
+}
+
+func (s *Service) CountByLanguage(ctx context.Context, language string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.CountByLanguage")
+	defer span.End()
+	return s.repo.CountByLanguage(ctx, language)
+}
+
+func (s *Service) CountTopConfessions(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.CountTopConfessions")
+	defer span.End()
+	return s.repo.CountVisible(ctx)
+}
+
+func (s *Service) CountTrendingWeekly(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.CountTrendingWeekly")
+	defer span.End()
+	return s.repo.CountSince(ctx, now().AddDate(0, 0, -7))
+}
+
+func (s *Service) CountTrendingMonthly(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.CountTrendingMonthly")
+	defer span.End()
+	return s.repo.CountSince(ctx, now().AddDate(0, -1, 0))
 }
 
-func (s *Service) GetTopConfessions(offset int, limit int) ([]Confession, error) {
-	return s.repo.GetTopConfessions(offset, limit)
+func (s *Service) CountHot(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.CountHot")
+	defer span.End()
+	return s.repo.CountVisible(ctx)
 }
 
-func (s *Service) TrendingWeekly(offset, limit int) ([]Confession, error) {
-	weekAgo := now().AddDate(0, 0, -7)
-	return s.repo.GetTopConfessionsSince(weekAgo, offset, limit)
+func (s *Service) CountHallOfFame(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.CountHallOfFame")
+	defer span.End()
+	return s.repo.CountVisible(ctx)
 }
 
-func (s *Service) TrendingMonthly(offset, limit int) ([]Confession, error) {
-	monthAgo := now().AddDate(0, -1, 0)
-	return s.repo.GetTopConfessionsSince(monthAgo, offset, limit)
+func (s *Service) CountUnsolved(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.CountUnsolved")
+	defer span.End()
+	return s.repo.CountUnsolved(ctx)
 }
 
-func (s *Service) HallOfFame(offset, limit int) ([]Confession, error) {
-	return s.repo.HallOfFame(offset, limit)
+func (s *Service) CountSearch(ctx context.Context, q, language, tag string, solved *bool) (int64, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.CountSearch")
+	defer span.End()
+	return s.repo.CountSearch(ctx, q, language, tag, solved)
 }
 
-func (s *Service) Random() (Confession, error) {
-	return s.repo.RandomConfession()
+func (s *Service) Random(ctx context.Context) (Confession, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.Random")
+	defer span.End()
+	return s.repo.RandomConfession(ctx)
 }
 
-// Search confessions by free text / language / tag
-func (s *Service) Search(q, language, tag string, offset, limit int) ([]Confession, error) {
-	return s.repo.Search(q, language, tag, offset, limit)
+// Search confessions by free text / language / tag / solved state
+func (s *Service) Search(ctx context.Context, q, language, tag string, solved *bool, page Page) ([]SearchHit, error) {
+	ctx, span := tracing.Start(ctx, "confession.Service.Search")
+	defer span.End()
+	return s.repo.Search(ctx, q, language, tag, solved, page)
 }
 
 func now() time.Time {
@@ -0,0 +1,87 @@
+// Package dialect picks the database driver from the DSN and papers over the
+// few SQL differences between the supported databases, PostgreSQL and SQLite.
+// Most queries need nothing: both speak RANDOM(), ON CONFLICT, CASE and
+// LOWER() alike.
+package dialect
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// GORM's names for the supported dialects
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// sqliteDefaults make a SQLite file behave like the PostgreSQL setup: foreign
+// keys enforced, readers not blocked by the writer, and writers queueing for
+// the lock (immediate transactions) instead of failing with "database is locked"
+var sqliteDefaults = [][2]string{
+	{"_foreign_keys", "1"},
+	{"_journal_mode", "WAL"},
+	{"_busy_timeout", "5000"},
+	{"_txlock", "immediate"},
+}
+
+// Open returns the GORM dialector for dsn, chosen by its scheme:
+//
+//   - postgres://… or postgresql://…, or a key=value DSN (host=… user=…)
+//   - sqlite://path/to/file.db (relative), sqlite:///var/lib/app.db (absolute),
+//     sqlite://:memory: or a file:… URI as go-sqlite3 takes it
+//
+// Nothing is connected yet.
+func Open(dsn string) (gorm.Dialector, error) {
+	scheme, rest, hasScheme := strings.Cut(dsn, ":")
+	switch {
+	case hasScheme && (scheme == "postgres" || scheme == "postgresql"):
+		return postgres.Open(dsn), nil
+	case hasScheme && scheme == "sqlite":
+		path, query, _ := strings.Cut(strings.TrimPrefix(rest, "//"), "?")
+		if path == "" {
+			return nil, fmt.Errorf("%q names no database file, e.g. sqlite://data/mydearbug.db", dsn)
+		}
+		return sqlite.Open(sqliteDSN("file:"+path, query)), nil
+	case hasScheme && scheme == "file":
+		path, query, _ := strings.Cut(dsn, "?")
+		return sqlite.Open(sqliteDSN(path, query)), nil
+	case !strings.Contains(dsn, "://") && strings.Contains(dsn, "="):
+		return postgres.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported database URL %q, use postgres://… or sqlite://path", redact(dsn))
+	}
+}
+
+// sqliteDSN adds the defaults the query does not set itself
+func sqliteDSN(path, query string) string {
+	params, _ := url.ParseQuery(query)
+	for _, d := range sqliteDefaults {
+		if !params.Has(d[0]) {
+			params.Set(d[0], d[1])
+		}
+	}
+	return path + "?" + params.Encode()
+}
+
+// redact drops the password from a URL so it can go into an error message
+func redact(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return dsn
+}
+
+// ILike is the case-insensitive LIKE operator: ILIKE on PostgreSQL, LIKE on
+// SQLite, which already ignores ASCII case
+func ILike(db *gorm.DB) string {
+	if db.Dialector.Name() == SQLite {
+		return "LIKE"
+	}
+	return "ILIKE"
+}
@@ -0,0 +1,101 @@
+package events
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Event types published by the services
+const (
+	ConfessionCreated = "confession.created"
+	ConfessionDeleted = "confession.deleted"
+	UpvoteRecorded    = "upvote.recorded"
+)
+
+// Event is a change on the site. Language and Tags describe the confession it
+// concerns so subscribers can filter; Data is what gets sent to clients.
+type Event struct {
+	ID       uint64
+	Type     string
+	Language string
+	Tags     []string
+	Data     any
+}
+
+// Publisher is what services publish events to
+type Publisher interface {
+	Publish(e Event)
+}
+
+// Filter selects events by the confession's language and tag; empty fields match anything
+type Filter struct {
+	Language string
+	Tag      string
+}
+
+func (f Filter) Match(e Event) bool {
+	if f.Language != "" && !strings.EqualFold(f.Language, e.Language) {
+		return false
+	}
+	if f.Tag != "" && !slices.ContainsFunc(e.Tags, func(t string) bool { return strings.EqualFold(t, f.Tag) }) {
+		return false
+	}
+	return true
+}
+
+// Subscription receives the matching events on C until it is closed
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	filter Filter
+}
+
+// Broker is an in-process pub/sub hub. Publishing never blocks: a subscriber
+// whose buffer is full misses the event.
+type Broker struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+	seq  atomic.Uint64
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[*Subscription]struct{})}
+}
+
+// Default is the broker shared by the services and the /stream endpoint
+var Default = NewBroker()
+
+func (b *Broker) Publish(e Event) {
+	e.ID = b.seq.Add(1)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default: // slow subscriber, drop
+		}
+	}
+}
+
+func (b *Broker) Subscribe(filter Filter, buffer int) *Subscription {
+	ch := make(chan Event, buffer)
+	s := &Subscription{C: ch, ch: ch, filter: filter}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+func (b *Broker) Unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+	b.mu.Unlock()
+}
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	subscriberBuffer  = 32
+	heartbeatInterval = 15 * time.Second
+)
+
+var (
+	closing     = make(chan struct{})
+	closingOnce sync.Once
+)
+
+// CloseStreams ends every open /stream response, and any opened later. Live
+// streams never finish on their own, so the server calls this when it shuts
+// down to let the connections drain.
+func CloseStreams() {
+	closingOnce.Do(func() { close(closing) })
+}
+
+// RegisterRoutes serves the live feed of the Default broker
+func RegisterRoutes(r *gin.Engine) {
+	// live feed over Server-Sent Events, optionally narrowed by ?language= and ?tag=
+	r.GET("/stream", func(c *gin.Context) {
+		filter := Filter{
+			Language: strings.TrimSpace(c.Query("language")),
+			Tag:      strings.TrimSpace(c.Query("tag")),
+		}
+		sub := Default.Subscribe(filter, subscriberBuffer)
+		defer Default.Unsubscribe(sub)
+
+		c.Header("Content-Type", sse.ContentType)
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no") // don't let proxies buffer the stream
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case <-closing:
+				return false
+			case <-heartbeat.C:
+				// comment line; keeps idle connections open through proxies
+				_, err := io.WriteString(w, ": ping\n\n")
+				return err == nil
+			case e, ok := <-sub.C:
+				if !ok {
+					return false
+				}
+				err := sse.Encode(w, sse.Event{
+					Id:    strconv.FormatUint(e.ID, 10),
+					Event: e.Type,
+					Data:  e.Data,
+				})
+				return err == nil
+			}
+		})
+	})
+}
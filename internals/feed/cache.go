@@ -0,0 +1,67 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// rendered is a generated feed document with its validators
+type rendered struct {
+	body         []byte
+	etag         string
+	lastModified time.Time
+	expires      time.Time
+}
+
+func newRendered(body []byte, lastModified time.Time, ttl time.Duration) *rendered {
+	sum := sha256.Sum256(body)
+	return &rendered{
+		body:         body,
+		etag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+		lastModified: lastModified,
+		expires:      time.Now().Add(ttl),
+	}
+}
+
+// cache keeps rendered feeds for a short while so polling readers don't reach the DB
+type cache struct {
+	mu      sync.Mutex
+	entries map[string]*rendered
+}
+
+func newCache() *cache {
+	return &cache{entries: make(map[string]*rendered)}
+}
+
+func (c *cache) get(key string) (*rendered, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.entries[key]
+	if !ok || time.Now().After(r.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return r, true
+}
+
+// upper bound on cached documents; per-tag and per-language feeds are open-ended
+const maxEntries = 1000
+
+func (c *cache) put(key string, r *rendered) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxEntries {
+		now := time.Now()
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[key] = r
+}
@@ -0,0 +1,138 @@
+package feed
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	confession "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	feedSize = 20
+
+	atomType = "application/atom+xml; charset=utf-8"
+	rssType  = "application/rss+xml; charset=utf-8"
+)
+
+// baseURL is the configured public base URL, or the scheme and host the
+// request came in on
+func baseURL(c *gin.Context, public string) string {
+	if base := strings.TrimRight(public, "/"); base != "" {
+		return base
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// notModified applies If-None-Match, then If-Modified-Since (RFC 9110 precedence)
+func notModified(c *gin.Context, r *rendered) bool {
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			if tag = strings.TrimSpace(tag); tag == r.etag || tag == "*" || tag == "W/"+r.etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !r.lastModified.IsZero() {
+		return !r.lastModified.Truncate(time.Second).After(ims)
+	}
+	return false
+}
+
+func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	repo := confession.NewRepo(db)
+	hotTrending := cfg.Trending.UseHot
+	ttl := cfg.Feeds.CacheTTL
+	feeds := newCache()
+	page := confession.Page{Limit: feedSize}
+
+	// serve renders the feed at most once per ttl and answers conditional requests
+	serve := func(c *gin.Context, contentType, title string, load func() ([]confession.Confession, error), render func(Feed) ([]byte, error)) {
+		base := baseURL(c, cfg.Feeds.PublicBaseURL)
+		key := base + c.Request.URL.Path
+		doc, ok := feeds.get(key)
+		if !ok {
+			items, err := load()
+			if err != nil {
+				problem.Internal(c, err, "failed to build feed")
+				return
+			}
+			f := Feed{Title: title, SelfURL: key, SiteURL: base, Updated: updated(items), Items: items}
+			if f.Updated.IsZero() {
+				f.Updated = time.Now().UTC()
+			}
+			body, err := render(f)
+			if err != nil {
+				problem.Internal(c, err, "failed to build feed")
+				return
+			}
+			doc = newRendered(body, f.Updated, ttl)
+			feeds.put(key, doc)
+		}
+
+		c.Header("ETag", doc.etag)
+		c.Header("Last-Modified", doc.lastModified.UTC().Format(http.TimeFormat))
+		c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(ttl.Seconds())))
+		if notModified(c, doc) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.Data(http.StatusOK, contentType, doc.body)
+	}
+
+	feedRoutes := r.Group("/feeds")
+
+	feedRoutes.GET("/latest.atom", func(c *gin.Context) {
+		serve(c, atomType, "My Dear Bug — latest confessions", func() ([]confession.Confession, error) {
+			return repo.List(c.Request.Context(), page, "")
+		}, Feed.Atom)
+	})
+
+	feedRoutes.GET("/trending.rss", func(c *gin.Context) {
+		serve(c, rssType, "My Dear Bug — trending this week", func() ([]confession.Confession, error) {
+			since := time.Now().AddDate(0, 0, -7)
+			if hotTrending {
+				return repo.HotSince(c.Request.Context(), since, page)
+			}
+			return repo.GetTopConfessionsSince(c.Request.Context(), since, page)
+		}, Feed.RSS)
+	})
+
+	// the .atom suffix is part of the path segment, so it is matched by hand
+	feedRoutes.GET("/tags/:name", func(c *gin.Context) {
+		name, ok := strings.CutSuffix(c.Param("name"), ".atom")
+		if !ok || strings.TrimSpace(name) == "" {
+			problem.NotFound(c, "unknown feed format")
+			return
+		}
+		serve(c, atomType, "My Dear Bug — confessions tagged "+name, func() ([]confession.Confession, error) {
+			hits, err := repo.Search(c.Request.Context(), "", "", name, nil, page)
+			items := make([]confession.Confession, 0, len(hits))
+			for _, h := range hits {
+				items = append(items, h.Confession)
+			}
+			return items, err
+		}, Feed.Atom)
+	})
+
+	feedRoutes.GET("/language/:language", func(c *gin.Context) {
+		language, ok := strings.CutSuffix(c.Param("language"), ".atom")
+		if !ok || strings.TrimSpace(language) == "" {
+			problem.NotFound(c, "unknown feed format")
+			return
+		}
+		serve(c, atomType, "My Dear Bug — "+language+" confessions", func() ([]confession.Confession, error) {
+			return repo.GetByLanguage(c.Request.Context(), language, page)
+		}, Feed.Atom)
+	})
+}
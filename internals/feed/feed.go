@@ -0,0 +1,184 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+
+	confession "github.com/Balaji01-4D/shit-happens/internals/confession"
+)
+
+// Feed is a rendered-format-agnostic listing, written out as Atom or RSS
+type Feed struct {
+	Title   string
+	SelfURL string
+	SiteURL string
+	Updated time.Time
+	Items   []confession.Confession
+}
+
+// updated is the newest change among the items, or the zero time for an empty feed
+func updated(items []confession.Confession) time.Time {
+	var t time.Time
+	for _, c := range items {
+		if c.UpdatedAt.After(t) {
+			t = c.UpdatedAt
+		}
+		if c.CreatedAt.After(t) {
+			t = c.CreatedAt
+		}
+	}
+	return t.UTC()
+}
+
+func (f Feed) itemURL(c confession.Confession) string {
+	return f.SiteURL + "/confessions/" + strconv.FormatUint(uint64(c.ID), 10)
+}
+
+func tagNames(c confession.Confession) []string {
+	names := make([]string, 0, len(c.Tags))
+	for _, t := range c.Tags {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+/* ---------- Atom (RFC 4287) ---------- */
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Categories []atomCategory `xml:"category"`
+	Summary    atomText       `xml:"summary"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+func (f Feed) Atom() ([]byte, error) {
+	out := atomFeed{
+		Title:   f.Title,
+		ID:      f.SelfURL,
+		Updated: f.Updated.Format(time.RFC3339),
+		Links:   []atomLink{{Href: f.SelfURL, Rel: "self"}, {Href: f.SiteURL, Rel: "alternate"}},
+		Author:  atomAuthor{Name: "My Dear Bug"},
+	}
+	for _, c := range f.Items {
+		e := atomEntry{
+			Title:     c.Title,
+			ID:        f.itemURL(c),
+			Link:      atomLink{Href: f.itemURL(c), Rel: "alternate"},
+			Published: c.CreatedAt.UTC().Format(time.RFC3339),
+			Updated:   c.UpdatedAt.UTC().Format(time.RFC3339),
+			Summary:   atomText{Type: "text", Body: c.Description},
+		}
+		for _, name := range tagNames(c) {
+			e.Categories = append(e.Categories, atomCategory{Term: name})
+		}
+		if c.Snippet != "" {
+			e.Content = &atomText{Type: "text", Body: c.Snippet}
+		}
+		out.Entries = append(out.Entries, e)
+	}
+	return marshal(out)
+}
+
+/* ---------- RSS 2.0 ---------- */
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Self          rssSelf   `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssSelf struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+}
+
+func (f Feed) RSS() ([]byte, error) {
+	out := rssDoc{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.SiteURL,
+			Description:   f.Title,
+			LastBuildDate: f.Updated.Format(time.RFC1123Z),
+			Self:          rssSelf{Href: f.SelfURL, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	for _, c := range f.Items {
+		out.Channel.Items = append(out.Channel.Items, rssItem{
+			Title:       c.Title,
+			Link:        f.itemURL(c),
+			GUID:        rssGUID{IsPermaLink: true, Value: f.itemURL(c)},
+			PubDate:     c.CreatedAt.UTC().Format(time.RFC1123Z),
+			Categories:  tagNames(c),
+			Description: c.Description,
+		})
+	}
+	return marshal(out)
+}
+
+func marshal(v any) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,93 @@
+// Package health serves the liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/logging"
+	"github.com/Balaji01-4D/shit-happens/internals/migrations"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// checkTimeout bounds each readiness check so a hung database fails the probe
+// instead of stalling it
+const checkTimeout = 2 * time.Second
+
+// errDegraded marks a check that is off but tolerated by the configuration;
+// the instance stays ready
+var errDegraded = errors.New("degraded")
+
+// Status answers both probes; Checks maps each readiness check to "ok",
+// "degraded" or "failing", the cause is only logged since the probes are public
+type Status struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// ping reports whether the database answers
+func ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// RegisterRoutes serves GET /healthz, which only tells the process is up, and
+// GET /readyz, which also needs the database and every migration applied.
+// Pending migrations only degrade readiness when
+// database.allowPendingMigrations lets the server start with them.
+func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	migrator, migratorErr := migrations.New(db)
+	migrated := func(ctx context.Context, db *gorm.DB) error {
+		if migratorErr != nil {
+			return migratorErr
+		}
+		err := migrator.Check(ctx)
+		if errors.Is(err, migrations.ErrPending) && cfg.Database.AllowPendingMigrations {
+			return fmt.Errorf("%w: %w", errDegraded, err)
+		}
+		return err
+	}
+
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, Status{Status: "ok"})
+	})
+
+	r.GET("/readyz", func(c *gin.Context) {
+		checks := map[string]func(context.Context, *gorm.DB) error{
+			"database":   ping,
+			"migrations": migrated,
+		}
+		status := Status{Status: "ok", Checks: make(map[string]string, len(checks))}
+		code := http.StatusOK
+		for name, check := range checks {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+			err := check(ctx, db)
+			cancel()
+			if errors.Is(err, errDegraded) {
+				logging.FromContext(c).Info("readiness check degraded", "check", name, "error", err)
+				if status.Status == "ok" {
+					status.Status = "degraded"
+				}
+				status.Checks[name] = "degraded"
+				continue
+			}
+			if err != nil {
+				logging.FromContext(c).Warn("readiness check failed", "check", name, "error", err)
+				status.Status = "unavailable"
+				status.Checks[name] = "failing"
+				code = http.StatusServiceUnavailable
+				continue
+			}
+			status.Checks[name] = "ok"
+		}
+		c.JSON(code, status)
+	})
+}
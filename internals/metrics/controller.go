@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Middleware records the count and latency of every request. Requests that
+// match no route are grouped under "unmatched" so scanners cannot blow up
+// the label cardinality.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterRoutes serves the Prometheus text format at /metrics behind the
+// given guards, e.g. the admin auth middleware
+func RegisterRoutes(r *gin.Engine, guards ...gin.HandlerFunc) {
+	handler := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+	r.GET("/metrics", append(guards, gin.WrapH(handler))...)
+}
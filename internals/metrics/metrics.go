@@ -0,0 +1,75 @@
+// Package metrics exposes Prometheus metrics for the API at /metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "mydearbug"
+
+// Registry holds every metric of the process; a dedicated registry keeps
+// third-party packages from adding to /metrics behind our back
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// RateLimited counts requests rejected with 429, by limiter ("post", "upvote")
+	RateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Requests rejected by a rate limiter.",
+	}, []string{"limiter"})
+
+	ConfessionsCreated = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "confessions_created_total",
+		Help:      "Confessions created.",
+	})
+
+	UpvotesRecorded = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upvotes_recorded_total",
+		Help:      "Confession upvotes recorded.",
+	})
+
+	dbDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "GORM statement latency by operation and table.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+)
+
+// TrackVisitors exports the size of a rate limiter's in-memory visitor map,
+// read on every scrape
+func TrackVisitors(limiter string, size func() int) {
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "rate_limit_visitors",
+		Help:        "Clients currently tracked by a rate limiter.",
+		ConstLabels: prometheus.Labels{"limiter": limiter},
+	}, func() float64 { return float64(size()) })
+}
@@ -1,22 +1,23 @@
 package middleware
 
 import (
-	"os"
-	"net/http"
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
 	"github.com/gin-gonic/gin"
 )
 
-func AdminAuthMiddleware() gin.HandlerFunc {
-	username := os.Getenv("ADMIN_USERNAME")
-	password := os.Getenv("ADMIN_PASSWORD")
-
+func AdminAuthMiddleware(admin config.Admin) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		user, pass, ok := c.Request.BasicAuth()
-		if !ok || user != username || pass != password {
-			c.Header("WWW-Authenticate", `Basic realm="Restricted"`)
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		if !IsAdmin(c, admin) {
+			problem.Unauthorized(c)
 			return
 		}
 		c.Next()
 	}
 }
+
+// IsAdmin reports whether the request carries the admin credentials, without aborting it
+func IsAdmin(c *gin.Context, admin config.Admin) bool {
+	user, pass, ok := c.Request.BasicAuth()
+	return ok && user == admin.Username && pass == admin.Password
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// header carrying the author's manage token on the author-or-admin routes
+const ManageTokenHeader = "X-Manage-Token"
+
+// CanManage reports whether token is the manage token issued for the confession
+type CanManage func(ctx context.Context, confessionID uint, token string) bool
+
+// AuthorOrAdmin allows the admin, or the author presenting the manage token of
+// the confession in the :id path parameter
+func AuthorOrAdmin(admin config.Admin, canManage CanManage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAdmin(c, admin) {
+			c.Next()
+			return
+		}
+		if token := c.GetHeader(ManageTokenHeader); token != "" {
+			id, err := strconv.Atoi(c.Param("id"))
+			if err == nil && id > 0 && canManage(c.Request.Context(), uint(id), token) {
+				c.Next()
+				return
+			}
+		}
+		problem.Unauthorized(c)
+	}
+}
@@ -1,10 +1,13 @@
 package middleware
 
 import (
-	"net/http"
+	"context"
 	"sync"
 	"time"
 
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/metrics"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
@@ -17,32 +20,50 @@ type Visitor struct {
 var visitors = make(map[string]*Visitor)
 var mu sync.Mutex
 
-// clear visitors for every ten minutes
 func init() {
-	go func() {
-		for {
-			time.Sleep(time.Minute * 10)
-			mu.Lock()
-
-			for ip, v := range visitors {
-				if time.Since(v.lastSeen) > time.Minute*10 {
-					delete(visitors, ip)
-				}
+	metrics.TrackVisitors("post", func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(visitors)
+	})
+}
+
+// CleanupVisitors forgets clients idle for ten minutes, every ten minutes,
+// until ctx is done
+func CleanupVisitors(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute * 10)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		mu.Lock()
+
+		for key, v := range visitors {
+			if time.Since(v.lastSeen) > time.Minute*10 {
+				delete(visitors, key)
 			}
-			mu.Unlock()
 		}
-	}()
+		mu.Unlock()
+	}
+}
+
+// newLimiter turns a configured limit into a token bucket
+func newLimiter(l config.Limit) *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(float64(l.Requests)/l.Per.Seconds()), l.Burst)
 }
 
-func getVisitor(ip string) *rate.Limiter {
+func getVisitor(key string, l config.Limit) *rate.Limiter {
 	mu.Lock()
 	defer mu.Unlock()
 
-	visitor, exists := visitors[ip]
+	visitor, exists := visitors[key]
 
 	if !exists {
-		limiter := rate.NewLimiter(10.0/3600.0, 3) // 10 posts per hour
-		visitors[ip] = &Visitor{limiter: limiter, lastSeen: time.Now()}
+		limiter := newLimiter(l)
+		visitors[key] = &Visitor{limiter: limiter, lastSeen: time.Now()}
 		return limiter
 	}
 
@@ -50,16 +71,17 @@ func getVisitor(ip string) *rate.Limiter {
 	return visitor.limiter
 }
 
-func PostRateLimitMiddleWare() gin.HandlerFunc {
+// PostRateLimitMiddleWare limits each client per route, so posting
+// confessions, comments and reports draw on separate buckets
+func PostRateLimitMiddleWare(l config.Limit) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
+		key := c.FullPath() + " " + c.ClientIP()
 
-		limiter := getVisitor(ip)
+		limiter := getVisitor(key, l)
 
 		if !limiter.Allow() {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error": "Too many requests - slow down",
-			})
+			metrics.RateLimited.WithLabelValues("post").Inc()
+			problem.TooManyRequests(c, "Too many requests - slow down")
 			return
 		}
 
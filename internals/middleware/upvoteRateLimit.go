@@ -1,12 +1,15 @@
 package middleware
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"net/http"
 	"sync"
 	"time"
 
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/metrics"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
@@ -31,13 +34,12 @@ func upvoteKey(c *gin.Context) string {
 	return c.ClientIP()
 }
 
-func getUpvoteLimiter(key string) *rate.Limiter {
+func getUpvoteLimiter(key string, l config.Limit) *rate.Limiter {
 	upvoteMu.Lock()
 	defer upvoteMu.Unlock()
 	v, ok := upvoteVisitors[key]
 	if !ok {
-		// Allow 1 upvote per 10 seconds on average with burst of 3
-		lim := rate.NewLimiter(rate.Every(10*time.Second), 3)
+		lim := newLimiter(l)
 		upvoteVisitors[key] = &upvoteVisitor{limiter: lim, lastSeen: time.Now()}
 		return lim
 	}
@@ -46,26 +48,40 @@ func getUpvoteLimiter(key string) *rate.Limiter {
 }
 
 func init() {
-	// cleanup goroutine
-	go func() {
-		for {
-			time.Sleep(5 * time.Minute)
-			upvoteMu.Lock()
-			for k, v := range upvoteVisitors {
-				if time.Since(v.lastSeen) > 10*time.Minute {
-					delete(upvoteVisitors, k)
-				}
+	metrics.TrackVisitors("upvote", func() int {
+		upvoteMu.Lock()
+		defer upvoteMu.Unlock()
+		return len(upvoteVisitors)
+	})
+}
+
+// CleanupUpvoteVisitors forgets clients idle for ten minutes, every five
+// minutes, until ctx is done
+func CleanupUpvoteVisitors(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		upvoteMu.Lock()
+		for k, v := range upvoteVisitors {
+			if time.Since(v.lastSeen) > 10*time.Minute {
+				delete(upvoteVisitors, k)
 			}
-			upvoteMu.Unlock()
 		}
-	}()
+		upvoteMu.Unlock()
+	}
 }
 
-func UpvoteRateLimitMiddleware() gin.HandlerFunc {
+func UpvoteRateLimitMiddleware(l config.Limit) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		key := upvoteKey(c)
-		if !getUpvoteLimiter(key).Allow() {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many upvotes, slow down"})
+		if !getUpvoteLimiter(key, l).Allow() {
+			metrics.RateLimited.WithLabelValues("upvote").Inc()
+			problem.TooManyRequests(c, "Too many upvotes, slow down")
 			return
 		}
 		c.Next()
@@ -0,0 +1,317 @@
+// Package migrations applies the numbered SQL migrations embedded in the
+// binary and records them in the schema_migrations table.
+//
+// A migration is a pair of files, NNNN_name.up.sql and NNNN_name.down.sql,
+// in the directory of each SQL dialect. Each runs in a transaction together
+// with its schema_migrations row, so a failed migration leaves no trace.
+package migrations
+
+import (
+	"cmp"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Balaji01-4D/shit-happens/internals/dialect"
+	"gorm.io/gorm"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Dialects have a directory of migrations each; every version exists in all
+// of them so both databases go through the same steps
+var Dialects = []string{dialect.Postgres, dialect.SQLite}
+
+// ErrPending is returned by Check when the database is behind the binary
+var ErrPending = errors.New("pending migrations")
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// ID is how files and logs refer to the migration, e.g. 0002_confession_search
+func (m Migration) ID() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Record is a row of schema_migrations: one applied migration
+type Record struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (Record) TableName() string { return "schema_migrations" }
+
+// Status is a migration and when it was applied, nil while pending
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Embedded returns the migrations shipped for a GORM dialect name
+func Embedded(dialect string) ([]Migration, error) {
+	sub, err := fs.Sub(files, dialect)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := Parse(sub)
+	if err != nil {
+		return nil, err
+	}
+	if len(ms) == 0 {
+		return nil, fmt.Errorf("no migrations for the %s dialect", dialect)
+	}
+	return ms, nil
+}
+
+// Parse reads the up/down pairs at the root of fsys, ordered by version.
+// Other files are ignored; a version without both halves is an error.
+func Parse(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		match := fileName.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	ms := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %s needs both an up and a down file", m.ID())
+		}
+		ms = append(ms, *m)
+	}
+	slices.SortFunc(ms, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return ms, nil
+}
+
+// Create adds an empty up/down pair to the directory of every dialect under
+// root, numbered after the highest version there, and returns the new files
+func Create(root, name string) ([]string, error) {
+	name = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.New("the migration needs a name, e.g. add_comment_edits")
+	}
+	next := Migration{Version: 1, Name: name}
+	for _, d := range Dialects {
+		ms, err := Parse(os.DirFS(filepath.Join(root, d)))
+		if err != nil {
+			return nil, err
+		}
+		if len(ms) > 0 && ms[len(ms)-1].Version >= next.Version {
+			next.Version = ms[len(ms)-1].Version + 1
+		}
+	}
+
+	var created []string
+	for _, d := range Dialects {
+		for _, half := range []struct{ suffix, comment string }{
+			{".up.sql", "the change"},
+			{".down.sql", "undo the change"},
+		} {
+			path := filepath.Join(root, d, next.ID()+half.suffix)
+			if err := writeNew(path, "-- "+next.ID()+" ("+d+"): "+half.comment+"\n"); err != nil {
+				return created, err
+			}
+			created = append(created, path)
+		}
+	}
+	return created, nil
+}
+
+// writeNew creates path, refusing to overwrite an existing file
+func writeNew(path, content string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Migrator applies a set of migrations to a database
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// New returns a Migrator with the embedded migrations of db's dialect
+func New(db *gorm.DB) (*Migrator, error) {
+	ms, err := Embedded(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return NewMigrator(db, ms), nil
+}
+
+// applied returns the schema_migrations rows by version; a database that
+// was never migrated has none
+func (m *Migrator) applied(ctx context.Context) (map[int64]Record, error) {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&Record{}) {
+		return map[int64]Record{}, nil
+	}
+	var records []Record
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]Record, len(records))
+	for _, r := range records {
+		byVersion[r.Version] = r
+	}
+	return byVersion, nil
+}
+
+// Status lists every known migration in order with its applied time
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Migration: mig}
+		if r, ok := applied[mig.Version]; ok {
+			s.AppliedAt = &r.AppliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Pending lists the migrations not applied yet, in order
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Check returns an error wrapping ErrPending that names the pending
+// migrations, if there are any
+func (m *Migrator) Check(ctx context.Context) error {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(pending))
+	for _, p := range pending {
+		ids = append(ids, p.ID())
+	}
+	return fmt.Errorf("%w: %s, run `migrate up`", ErrPending, strings.Join(ids, ", "))
+}
+
+// Up applies every pending migration in order and returns the ones applied;
+// it stops at the first failure, which is rolled back
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 && !m.db.Migrator().HasTable(&Record{}) {
+		if err := m.db.WithContext(ctx).Migrator().CreateTable(&Record{}); err != nil {
+			return nil, err
+		}
+	}
+	var done []Migration
+	for _, mig := range pending {
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(mig.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&Record{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now().UTC()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %s failed: %w", mig.ID(), err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down rolls back the latest applied migration and returns it, or nil when
+// nothing is applied
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) == 0 {
+		return nil, nil
+	}
+	latest := slices.Max(slices.Collect(maps.Keys(applied)))
+	i := slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version == latest })
+	if i < 0 {
+		unknown := Migration{Version: latest, Name: applied[latest].Name}
+		return nil, fmt.Errorf("migration %s is applied but unknown to this binary", unknown.ID())
+	}
+	mig := m.migrations[i]
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(mig.Down).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Record{}, mig.Version).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rolling back %s failed: %w", mig.ID(), err)
+	}
+	return &mig, nil
+}
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS reactions;
+DROP TABLE IF EXISTS comment_upvotes;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS reports;
+DROP TABLE IF EXISTS upvotes;
+DROP TABLE IF EXISTS reaction_counts;
+DROP TABLE IF EXISTS confession_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS confession_revisions;
+DROP TABLE IF EXISTS confessions;
@@ -0,0 +1,138 @@
+-- The schema GORM's AutoMigrate used to create. IF NOT EXISTS lets this adopt
+-- a database that AutoMigrate already set up.
+
+CREATE TABLE IF NOT EXISTS confessions (
+    id bigserial PRIMARY KEY,
+    title varchar(255) NOT NULL,
+    description text,
+    language varchar(50),
+    snippet text,
+    sentiment varchar(20),
+    is_flagged boolean DEFAULT false,
+    created_at timestamptz,
+    updated_at timestamptz,
+    upvotes bigint DEFAULT 0,
+    hot_score decimal DEFAULT 0,
+    moderation_status varchar(20) DEFAULT '',
+    manage_token_hash varchar(64),
+    comment_count bigint DEFAULT 0,
+    solved boolean DEFAULT false,
+    accepted_comment_id bigint
+);
+CREATE INDEX IF NOT EXISTS idx_confessions_is_flagged ON confessions (is_flagged);
+CREATE INDEX IF NOT EXISTS idx_confessions_hot_score ON confessions (hot_score);
+CREATE INDEX IF NOT EXISTS idx_confessions_solved ON confessions (solved);
+
+CREATE TABLE IF NOT EXISTS confession_revisions (
+    id bigserial PRIMARY KEY,
+    confession_id bigint NOT NULL,
+    title varchar(255) NOT NULL,
+    description text,
+    language varchar(50),
+    snippet text,
+    tags text,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_confession_revisions_confession_id ON confession_revisions (confession_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id bigserial PRIMARY KEY,
+    name varchar(20)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+
+CREATE TABLE IF NOT EXISTS confession_tags (
+    confession_id bigint,
+    tag_id bigint,
+    PRIMARY KEY (confession_id, tag_id),
+    CONSTRAINT fk_confession_tags_confession FOREIGN KEY (confession_id) REFERENCES confessions (id),
+    CONSTRAINT fk_confession_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+CREATE TABLE IF NOT EXISTS reaction_counts (
+    confession_id bigint,
+    kind varchar(20),
+    count bigint DEFAULT 0,
+    PRIMARY KEY (confession_id, kind),
+    CONSTRAINT fk_confessions_reactions FOREIGN KEY (confession_id) REFERENCES confessions (id)
+);
+
+CREATE TABLE IF NOT EXISTS upvotes (
+    id bigserial PRIMARY KEY,
+    confession_id bigint,
+    ip_hash varchar(64),
+    client_hash varchar(64),
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_upvote_conf_ip ON upvotes (confession_id, ip_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_upvote_conf_client ON upvotes (confession_id, client_hash);
+
+CREATE TABLE IF NOT EXISTS reports (
+    id bigserial PRIMARY KEY,
+    confession_id bigint,
+    ip_hash varchar(64),
+    reason varchar(20) NOT NULL,
+    note varchar(500),
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_report_conf_ip ON reports (confession_id, ip_hash);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id bigserial PRIMARY KEY,
+    confession_id bigint NOT NULL,
+    parent_id bigint,
+    depth bigint DEFAULT 0,
+    body text NOT NULL,
+    upvotes bigint DEFAULT 0,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_comments_confession_id ON comments (confession_id);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+
+CREATE TABLE IF NOT EXISTS comment_upvotes (
+    id bigserial PRIMARY KEY,
+    comment_id bigint,
+    ip_hash varchar(64),
+    client_hash varchar(64),
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cupvote_comment_ip ON comment_upvotes (comment_id, ip_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cupvote_comment_client ON comment_upvotes (comment_id, client_hash);
+
+CREATE TABLE IF NOT EXISTS reactions (
+    id bigserial PRIMARY KEY,
+    confession_id bigint,
+    kind varchar(20),
+    ip_hash varchar(64),
+    client_hash varchar(64),
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reaction_conf_kind_ip ON reactions (confession_id, kind, ip_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reaction_conf_kind_client ON reactions (confession_id, kind, client_hash);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id bigserial PRIMARY KEY,
+    url varchar(2048) NOT NULL,
+    secret varchar(128) NOT NULL,
+    event_types text,
+    language varchar(50),
+    tag varchar(50),
+    active boolean DEFAULT true,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_active ON webhook_subscriptions (active);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial PRIMARY KEY,
+    subscription_id bigint NOT NULL,
+    event_id bigint,
+    event_type varchar(50),
+    attempt bigint,
+    status_code bigint,
+    error varchar(500),
+    success boolean,
+    duration_ms bigint,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries (created_at);
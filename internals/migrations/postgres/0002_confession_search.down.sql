@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_confessions_search_vector;
+ALTER TABLE confessions DROP COLUMN IF EXISTS search_vector;
+DROP FUNCTION IF EXISTS confession_search_document(text, text, text);
@@ -0,0 +1,18 @@
+-- Full-text search: a weighted tsvector per confession (title > description >
+-- snippet, code indexed with the 'simple' config so identifiers are not
+-- stemmed). confession_search_document is the only definition of the
+-- document; the repository calls it to keep the column current on every
+-- write, this backfills it.
+
+CREATE OR REPLACE FUNCTION confession_search_document(title text, description text, snippet text)
+RETURNS tsvector LANGUAGE sql IMMUTABLE AS $$
+    SELECT setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+           setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+           setweight(to_tsvector('simple', coalesce(snippet, '')), 'C')
+$$;
+
+ALTER TABLE confessions ADD COLUMN IF NOT EXISTS search_vector tsvector;
+CREATE INDEX IF NOT EXISTS idx_confessions_search_vector ON confessions USING GIN (search_vector);
+
+UPDATE confessions SET search_vector = confession_search_document(title, description, snippet)
+WHERE search_vector IS NULL;
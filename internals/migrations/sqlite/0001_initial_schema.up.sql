@@ -0,0 +1,137 @@
+-- The same schema as postgres/0001_initial_schema.up.sql in SQLite's types.
+
+CREATE TABLE IF NOT EXISTS confessions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    title text NOT NULL,
+    description text,
+    language text,
+    snippet text,
+    sentiment text,
+    is_flagged numeric DEFAULT false,
+    created_at datetime,
+    updated_at datetime,
+    upvotes integer DEFAULT 0,
+    hot_score real DEFAULT 0,
+    moderation_status text DEFAULT '',
+    manage_token_hash text,
+    comment_count integer DEFAULT 0,
+    solved numeric DEFAULT false,
+    accepted_comment_id integer
+);
+CREATE INDEX IF NOT EXISTS idx_confessions_is_flagged ON confessions (is_flagged);
+CREATE INDEX IF NOT EXISTS idx_confessions_hot_score ON confessions (hot_score);
+CREATE INDEX IF NOT EXISTS idx_confessions_solved ON confessions (solved);
+
+CREATE TABLE IF NOT EXISTS confession_revisions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    confession_id integer NOT NULL,
+    title text NOT NULL,
+    description text,
+    language text,
+    snippet text,
+    tags text,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_confession_revisions_confession_id ON confession_revisions (confession_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    name text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+
+CREATE TABLE IF NOT EXISTS confession_tags (
+    confession_id integer,
+    tag_id integer,
+    PRIMARY KEY (confession_id, tag_id),
+    CONSTRAINT fk_confession_tags_confession FOREIGN KEY (confession_id) REFERENCES confessions (id),
+    CONSTRAINT fk_confession_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+CREATE TABLE IF NOT EXISTS reaction_counts (
+    confession_id integer,
+    kind text,
+    count integer DEFAULT 0,
+    PRIMARY KEY (confession_id, kind),
+    CONSTRAINT fk_confessions_reactions FOREIGN KEY (confession_id) REFERENCES confessions (id)
+);
+
+CREATE TABLE IF NOT EXISTS upvotes (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    confession_id integer,
+    ip_hash text,
+    client_hash text,
+    created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_upvote_conf_ip ON upvotes (confession_id, ip_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_upvote_conf_client ON upvotes (confession_id, client_hash);
+
+CREATE TABLE IF NOT EXISTS reports (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    confession_id integer,
+    ip_hash text,
+    reason text NOT NULL,
+    note text,
+    created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_report_conf_ip ON reports (confession_id, ip_hash);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    confession_id integer NOT NULL,
+    parent_id integer,
+    depth integer DEFAULT 0,
+    body text NOT NULL,
+    upvotes integer DEFAULT 0,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_comments_confession_id ON comments (confession_id);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments (parent_id);
+
+CREATE TABLE IF NOT EXISTS comment_upvotes (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    comment_id integer,
+    ip_hash text,
+    client_hash text,
+    created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cupvote_comment_ip ON comment_upvotes (comment_id, ip_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cupvote_comment_client ON comment_upvotes (comment_id, client_hash);
+
+CREATE TABLE IF NOT EXISTS reactions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    confession_id integer,
+    kind text,
+    ip_hash text,
+    client_hash text,
+    created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reaction_conf_kind_ip ON reactions (confession_id, kind, ip_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reaction_conf_kind_client ON reactions (confession_id, kind, client_hash);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    url text NOT NULL,
+    secret text NOT NULL,
+    event_types text,
+    language text,
+    tag text,
+    active numeric DEFAULT true,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_active ON webhook_subscriptions (active);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    subscription_id integer NOT NULL,
+    event_id integer,
+    event_type text,
+    attempt integer,
+    status_code integer,
+    error text,
+    success numeric,
+    duration_ms integer,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries (created_at);
@@ -0,0 +1,3 @@
+-- Nothing to do: the FTS5 index only exists in builds with -tags sqlite_fts5,
+-- so the server creates it at startup when the build supports it
+-- (confession.EnsureSearchIndex) and searches by pattern otherwise.
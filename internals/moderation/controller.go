@@ -0,0 +1,100 @@
+package moderation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/middleware"
+	"github.com/Balaji01-4D/shit-happens/internals/pagination"
+	"github.com/Balaji01-4D/shit-happens/internals/params"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	repo := NewRepo(db)
+	svc := NewService(repo, cfg.Moderation.ReportFlagThreshold)
+
+	// report a confession to the moderators
+	r.POST("/confessions/:id/report", middleware.PostRateLimitMiddleWare(cfg.RateLimit.Reports), func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		var dto ReportRequest
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			problem.InvalidBody(c, err)
+			return
+		}
+
+		hash := sha256.Sum256([]byte(c.ClientIP()))
+		ipHash := hex.EncodeToString(hash[:])
+
+		if repo.HasReported(c.Request.Context(), id, ipHash) {
+			c.JSON(http.StatusOK, gin.H{"message": "already reported"})
+			return
+		}
+
+		if _, err := svc.Report(c.Request.Context(), id, ipHash, dto); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "confession not found")
+				return
+			}
+			// Possible race with a concurrent report from the same IP
+			if repo.HasReported(c.Request.Context(), id, ipHash) {
+				c.JSON(http.StatusOK, gin.H{"message": "already reported"})
+				return
+			}
+			problem.Internal(c, err, "failed to report")
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "report recorded"})
+	})
+
+	adminRoutes := r.Group("/admin/moderation", middleware.AdminAuthMiddleware(cfg.Admin))
+
+	adminRoutes.GET("/queue", func(c *gin.Context) {
+		offset, limit := pagination.Parse(c, cfg.Pagination.Moderation)
+		items, err := svc.Queue(c.Request.Context(), offset, limit)
+		if err != nil {
+			problem.Internal(c, err, "failed to fetch")
+			return
+		}
+		c.JSON(http.StatusOK, items)
+	})
+
+	adminRoutes.POST("/:id/approve", func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		if err := svc.Approve(c.Request.Context(), id); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "not in moderation queue")
+				return
+			}
+			problem.Internal(c, err, "failed to approve")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "confession approved"})
+	})
+
+	adminRoutes.POST("/:id/reject", func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		if err := svc.Reject(c.Request.Context(), id); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "not in moderation queue")
+				return
+			}
+			problem.Internal(c, err, "failed to reject")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "confession rejected"})
+	})
+}
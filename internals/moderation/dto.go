@@ -0,0 +1,14 @@
+package moderation
+
+import confession "github.com/Balaji01-4D/shit-happens/internals/confession"
+
+type ReportRequest struct {
+	Reason string `json:"reason" binding:"required,oneof=spam offensive personal_info off_topic other"`
+	Note   string `json:"note" binding:"omitempty,max=500"`
+}
+
+// QueueItem is a flagged confession awaiting review together with its reports
+type QueueItem struct {
+	Confession confession.Confession `json:"confession"`
+	Reports    []Report              `json:"reports"`
+}
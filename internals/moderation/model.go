@@ -0,0 +1,16 @@
+package moderation
+
+import "time"
+
+// Report is a user's complaint about a confession.
+//
+// (confession_id, ip_hash) is unique so a single visitor cannot push a post
+// over the auto-flag threshold alone.
+type Report struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ConfessionID uint      `gorm:"uniqueIndex:idx_report_conf_ip" json:"confessionId"`
+	IPHash       string    `gorm:"size:64;uniqueIndex:idx_report_conf_ip" json:"-"`
+	Reason       string    `gorm:"size:20;not null" json:"reason"`
+	Note         string    `gorm:"size:500" json:"note,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
@@ -0,0 +1,101 @@
+package moderation
+
+import (
+	"context"
+
+	confession "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	DB *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// HasReported checks whether this IP already reported the confession
+func (r *Repository) HasReported(ctx context.Context, confessionID uint, ipHash string) bool {
+	var report Report
+	err := r.DB.WithContext(ctx).Where("confession_id = ? AND ip_hash = ?", confessionID, ipHash).First(&report).Error
+	return err == nil
+}
+
+// SaveAndFlag stores the report and flags the confession for review once it
+// reaches the threshold. Confessions a moderator already reviewed are not re-flagged.
+func (r *Repository) SaveAndFlag(ctx context.Context, report *Report, threshold int) (flagged bool, err error) {
+	tx := r.DB.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return false, err
+	}
+
+	if err := tx.Create(report).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	var count int64
+	if err := tx.Model(&Report{}).Where("confession_id = ?", report.ConfessionID).Count(&count).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if count >= int64(threshold) {
+		res := tx.Model(&confession.Confession{}).
+			Where("id = ? AND moderation_status = ?", report.ConfessionID, confession.ModerationNone).
+			Updates(map[string]any{"is_flagged": true, "moderation_status": confession.ModerationPending})
+		if res.Error != nil {
+			tx.Rollback()
+			return false, res.Error
+		}
+		flagged = res.RowsAffected > 0
+	}
+
+	return flagged, tx.Commit().Error
+}
+
+// Queue returns flagged confessions awaiting review, oldest first
+func (r *Repository) Queue(ctx context.Context, offset, limit int) ([]confession.Confession, error) {
+	var confessions []confession.Confession
+	err := r.DB.WithContext(ctx).
+		Preload("Tags").Preload("Reactions").
+		Where("is_flagged = ? AND moderation_status = ?", true, confession.ModerationPending).
+		Offset(offset).
+		Limit(limit).
+		Order("created_at ASC").
+		Find(&confessions).Error
+	return confessions, err
+}
+
+func (r *Repository) ReportsFor(ctx context.Context, confessionIDs []uint) ([]Report, error) {
+	var reports []Report
+	if len(confessionIDs) == 0 {
+		return reports, nil
+	}
+	err := r.DB.WithContext(ctx).
+		Where("confession_id IN ?", confessionIDs).
+		Order("created_at ASC").
+		Find(&reports).Error
+	return reports, err
+}
+
+// SetStatus records a moderator decision; only pending confessions can be reviewed
+func (r *Repository) SetStatus(ctx context.Context, confessionID uint, status string, flagged bool) error {
+	res := r.DB.WithContext(ctx).Model(&confession.Confession{}).
+		Where("id = ? AND moderation_status = ?", confessionID, confession.ModerationPending).
+		Updates(map[string]any{"is_flagged": flagged, "moderation_status": status})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *Repository) ConfessionExists(ctx context.Context, id uint) bool {
+	var count int64
+	r.DB.WithContext(ctx).Model(&confession.Confession{}).Where("id = ?", id).Count(&count)
+	return count > 0
+}
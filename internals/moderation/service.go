@@ -0,0 +1,84 @@
+package moderation
+
+import (
+	"context"
+	"time"
+
+	confession "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/tracing"
+	"gorm.io/gorm"
+)
+
+// number of distinct reporters that hides a confession until reviewed
+const defaultFlagThreshold = 3
+
+type Service struct {
+	repo      *Repository
+	threshold int
+}
+
+func NewService(r *Repository, threshold int) *Service {
+	if threshold <= 0 {
+		threshold = defaultFlagThreshold
+	}
+	return &Service{repo: r, threshold: threshold}
+}
+
+// Report records a complaint and reports whether it pushed the confession into the queue
+func (s *Service) Report(ctx context.Context, confessionID uint, ipHash string, dto ReportRequest) (bool, error) {
+	ctx, span := tracing.Start(ctx, "moderation.Service.Report")
+	defer span.End()
+	if !s.repo.ConfessionExists(ctx, confessionID) {
+		return false, gorm.ErrRecordNotFound
+	}
+	report := &Report{
+		ConfessionID: confessionID,
+		IPHash:       ipHash,
+		Reason:       dto.Reason,
+		Note:         dto.Note,
+		CreatedAt:    time.Now(),
+	}
+	return s.repo.SaveAndFlag(ctx, report, s.threshold)
+}
+
+func (s *Service) Queue(ctx context.Context, offset, limit int) ([]QueueItem, error) {
+	ctx, span := tracing.Start(ctx, "moderation.Service.Queue")
+	defer span.End()
+	confessions, err := s.repo.Queue(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(confessions))
+	for _, c := range confessions {
+		ids = append(ids, c.ID)
+	}
+	reports, err := s.repo.ReportsFor(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byConfession := make(map[uint][]Report, len(confessions))
+	for _, rep := range reports {
+		byConfession[rep.ConfessionID] = append(byConfession[rep.ConfessionID], rep)
+	}
+
+	items := make([]QueueItem, 0, len(confessions))
+	for _, c := range confessions {
+		items = append(items, QueueItem{Confession: c, Reports: byConfession[c.ID]})
+	}
+	return items, nil
+}
+
+// Approve makes the confession visible again; later reports no longer re-flag it
+func (s *Service) Approve(ctx context.Context, confessionID uint) error {
+	ctx, span := tracing.Start(ctx, "moderation.Service.Approve")
+	defer span.End()
+	return s.repo.SetStatus(ctx, confessionID, confession.ModerationApproved, false)
+}
+
+// Reject keeps the confession hidden for good
+func (s *Service) Reject(ctx context.Context, confessionID uint) error {
+	ctx, span := tracing.Start(ctx, "moderation.Service.Reject")
+	defer span.End()
+	return s.repo.SetStatus(ctx, confessionID, confession.ModerationRejected, true)
+}
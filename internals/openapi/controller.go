@@ -0,0 +1,32 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/gin-gonic/gin"
+)
+
+// docsPage renders /openapi.json in the browser; it is self-contained so the
+// docs work offline and under a strict CSP
+//
+//go:embed docs.html
+var docsPage []byte
+
+func RegisterRoutes(r *gin.Engine, cfg *config.Config) {
+	// the spec only depends on the code and the configuration, so it is built once
+	spec, err := json.Marshal(Build(cfg))
+	if err != nil {
+		panic("openapi: " + err.Error())
+	}
+
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+	})
+
+	r.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+	})
+}
@@ -0,0 +1,558 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/comment"
+	"github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/health"
+	"github.com/Balaji01-4D/shit-happens/internals/middleware"
+	"github.com/Balaji01-4D/shit-happens/internals/moderation"
+	"github.com/Balaji01-4D/shit-happens/internals/pagination"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
+	"github.com/Balaji01-4D/shit-happens/internals/tag"
+	"github.com/Balaji01-4D/shit-happens/internals/upvote"
+	"github.com/Balaji01-4D/shit-happens/internals/webhook"
+)
+
+// MessageBody acknowledges a write that has nothing else to return
+type MessageBody struct {
+	Message string `json:"message"`
+}
+
+// UpvoteState answers GET /confessions/:id/upvote
+type UpvoteState struct {
+	Upvoted bool `json:"upvoted"`
+}
+
+// ReactionKinds answers GET /reactions
+type ReactionKinds struct {
+	Kinds []string `json:"kinds"`
+}
+
+// security requirements; the admin routes take basic auth, author routes
+// accept either the admin or the confession's manage token
+var (
+	adminOnly     = []map[string][]string{{"admin": {}}}
+	authorOrAdmin = []map[string][]string{{"admin": {}}, {"manageToken": {}}}
+)
+
+// builder collects operations; every route registered by a RegisterRoutes
+// function must be added here, tests/openapi_test.go enforces it
+type builder struct {
+	doc   *Document
+	gen   *generator
+	pages config.Pagination
+}
+
+// op starts an operation; path uses OpenAPI {param} syntax
+func (b *builder) op(method, path, tag, id, summary string) *Operation {
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = PathItem{}
+		b.doc.Paths[path] = item
+	}
+	method = strings.ToLower(method)
+	if _, dup := item[method]; dup {
+		panic(fmt.Sprintf("openapi: %s %s documented twice", method, path))
+	}
+	o := &Operation{Tags: []string{tag}, OperationID: id, Summary: summary, Responses: map[string]*Response{}}
+	item[method] = o
+	return o
+}
+
+func (o *Operation) params(ps ...Parameter) *Operation {
+	o.Parameters = append(o.Parameters, ps...)
+	return o
+}
+
+func (o *Operation) secured(req []map[string][]string) *Operation {
+	o.Security = req
+	return o
+}
+
+func (o *Operation) respond(status int, r *Response) *Operation {
+	o.Responses[fmt.Sprint(status)] = r
+	return o
+}
+
+// body documents a JSON request body bound with ShouldBindJSON
+func (b *builder) body(o *Operation, v any) *Operation {
+	o.RequestBody = &RequestBody{Required: true, Content: b.json(v)}
+	return o
+}
+
+func (b *builder) json(v any) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: b.gen.schemaOf(v)}}
+}
+
+func (b *builder) ok(desc string, v any) *Response {
+	return &Response{Description: desc, Content: b.json(v)}
+}
+
+func (b *builder) message(desc string) *Response {
+	return b.ok(desc, MessageBody{})
+}
+
+// fail documents an RFC 7807 error; clients branch on its code
+func (b *builder) fail(desc string) *Response {
+	return &Response{
+		Description: desc,
+		Content:     map[string]MediaType{problem.ContentType: {Schema: b.gen.schemaOf(problem.Problem{})}},
+	}
+}
+
+// list documents a listing that honours the envelope and, when cursor is
+// true, keyset pagination; T is the item type
+func list[T any](b *builder, desc string, cursor bool) *Response {
+	shapes := []*Schema{
+		{Type: "array", Items: b.gen.schemaOf(*new(T))},
+		b.gen.schemaOf(pagination.Envelope[T]{}),
+	}
+	if cursor {
+		shapes = append(shapes, b.gen.schemaOf(confession.CursorPage[T]{}))
+		desc += ". A bare array by default, the envelope with ?envelope=1 or the " +
+			pagination.EnvelopeMediaType + " Accept header, {items, nextCursor} with ?cursor="
+	} else {
+		desc += ". A bare array by default, the envelope with ?envelope=1 or the " +
+			pagination.EnvelopeMediaType + " Accept header"
+	}
+	return &Response{
+		Description: desc,
+		Headers: map[string]Header{
+			"Link": {Description: "RFC 8288 first/prev/next/last links", Schema: &Schema{Type: "string"}},
+		},
+		Content: map[string]MediaType{
+			"application/json":           {Schema: &Schema{OneOf: shapes}},
+			pagination.EnvelopeMediaType: {Schema: shapes[1]},
+		},
+	}
+}
+
+func text(desc, mediaType string) *Response {
+	return &Response{Description: desc, Content: map[string]MediaType{mediaType: {Schema: &Schema{Type: "string"}}}}
+}
+
+func pathID(desc string) Parameter {
+	return Parameter{Name: "id", In: "path", Required: true, Description: desc, Schema: &Schema{Type: "integer", Minimum: ptr(1.0)}}
+}
+
+func pathString(name, desc string) Parameter {
+	return Parameter{Name: name, In: "path", Required: true, Description: desc, Schema: &Schema{Type: "string"}}
+}
+
+func query(name, desc string, s *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: desc, Schema: s}
+}
+
+// offsetParams mirror each package's parsePagination
+func offsetParams(size config.PageSize) []Parameter {
+	return []Parameter{
+		query("offset", "Rows to skip", &Schema{Type: "integer", Minimum: ptr(0.0), Default: 0}),
+		query("limit", fmt.Sprintf("Page size (default: %d, max: %d)", size.DefaultLimit, size.MaxLimit),
+			&Schema{Type: "integer", Minimum: ptr(1.0), Maximum: ptr(float64(size.MaxLimit)), Default: size.DefaultLimit}),
+	}
+}
+
+var envelopeParam = query("envelope", "Wrap the page in {items, total, offset, limit, next, prev}", &Schema{Type: "boolean"})
+
+// confessionListParams mirror confession.parsePage
+func (b *builder) confessionListParams() []Parameter {
+	return append(offsetParams(b.pages.Confessions), envelopeParam,
+		query("cursor", "Keyset pagination; pass an empty value for the first page, then nextCursor", &Schema{Type: "string"}),
+		query("reaction", "Order by the count of this reaction kind instead, see GET /reactions", &Schema{Type: "string"}),
+	)
+}
+
+// Build assembles the document from the route table below; the page sizes
+// are the configured ones
+func Build(cfg *config.Config) *Document {
+	b := &builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info: Info{
+				Title:       "My Dear Bug API",
+				Description: "Anonymous developer confessions: post, vote, react, comment and follow them live.",
+				Version:     "1.0.0",
+			},
+			Paths: map[string]PathItem{},
+			Components: Components{
+				SecuritySchemes: map[string]SecurityScheme{
+					"admin": {Type: "http", Scheme: "basic", Description: "ADMIN_USERNAME / ADMIN_PASSWORD"},
+					"manageToken": {Type: "apiKey", In: "header", Name: middleware.ManageTokenHeader,
+						Description: "The manageToken returned once when the confession was created"},
+				},
+			},
+			Tags: []Tag{
+				{Name: "confessions"}, {Name: "upvotes"}, {Name: "reactions"}, {Name: "comments"},
+				{Name: "tags"}, {Name: "moderation"}, {Name: "webhooks"}, {Name: "feeds"},
+				{Name: "stream"}, {Name: "meta"},
+			},
+		},
+		gen:   newGenerator(),
+		pages: cfg.Pagination,
+	}
+	b.gen.override(reflect.TypeFor[confession.ReactionCounts](), &Schema{
+		Type:                 "object",
+		Description:          "Count per reaction kind",
+		AdditionalProperties: &Schema{Type: "integer"},
+	})
+
+	b.confessions()
+	b.votes()
+	b.comments()
+	b.tags()
+	b.moderation()
+	b.webhooks()
+	b.feeds()
+	b.meta()
+
+	if p, ok := b.gen.schemas["Problem"]; ok {
+		p.Properties["code"].Enum = problem.Codes
+	}
+	b.doc.Components.Schemas = b.gen.schemas
+	return b.doc
+}
+
+func (b *builder) confessions() {
+	id := pathID("Confession ID")
+	badRequest := b.fail("Invalid parameters")
+	notFound := b.fail("Confession not found")
+	unauthorized := b.fail("Neither admin credentials nor the manage token")
+
+	b.op(http.MethodGet, "/confessions", "confessions", "listConfessions", "List confessions, newest first").
+		params(b.confessionListParams()...).
+		params(query("sentiment", "Only confessions with this mood", &Schema{Type: "string", Enum: []string{"positive", "negative", "neutral"}})).
+		respond(http.StatusOK, list[confession.Confession](b, "Confessions", true)).
+		respond(http.StatusBadRequest, badRequest)
+
+	b.body(b.op(http.MethodPost, "/confessions", "confessions", "createConfession", "Post a confession"), confession.ConfessionRequest{}).
+		respond(http.StatusCreated, b.ok("Created; manageToken is only ever shown here", confession.CreateConfessionResponse{})).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusTooManyRequests, b.fail("Posting rate limit exceeded"))
+
+	b.op(http.MethodGet, "/confessions/{id}", "confessions", "getConfession", "Get a confession").
+		params(id).
+		respond(http.StatusOK, b.ok("The confession", confession.Confession{})).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusNotFound, notFound)
+
+	b.body(b.op(http.MethodPatch, "/confessions/{id}", "confessions", "updateConfession", "Edit a confession; the previous version is kept as a revision"), confession.ConfessionUpdateRequest{}).
+		params(id).
+		secured(authorOrAdmin).
+		respond(http.StatusOK, b.ok("The updated confession", confession.Confession{})).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusUnauthorized, unauthorized).
+		respond(http.StatusNotFound, notFound)
+
+	b.op(http.MethodDelete, "/confessions/{id}", "confessions", "deleteConfession", "Delete a confession").
+		params(id).
+		secured(authorOrAdmin).
+		respond(http.StatusOK, b.message("Deleted")).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusUnauthorized, unauthorized).
+		respond(http.StatusNotFound, notFound)
+
+	b.op(http.MethodGet, "/confessions/{id}/revisions", "confessions", "listRevisions", "Edit history, newest first").
+		params(id).
+		params(offsetParams(b.pages.Confessions)...).
+		params(envelopeParam).
+		respond(http.StatusOK, list[confession.ConfessionRevision](b, "Revisions", false)).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusNotFound, notFound)
+
+	b.op(http.MethodGet, "/confessions/language/{language}", "confessions", "listByLanguage", "List confessions in a language").
+		params(pathString("language", "Programming language, case-insensitive")).
+		params(b.confessionListParams()...).
+		respond(http.StatusOK, list[confession.Confession](b, "Confessions", true)).
+		respond(http.StatusBadRequest, badRequest)
+
+	listings := []struct{ path, id, summary string }{
+		{"/confessions/top", "listTop", "Most upvoted confessions"},
+		{"/confessions/trending/weekly", "listTrendingWeekly", "Trending over the last 7 days"},
+		{"/confessions/trending/monthly", "listTrendingMonthly", "Trending over the last 30 days"},
+		{"/confessions/hot", "listHot", "Ranked by time-decayed votes"},
+		{"/confessions/hall-of-fame", "listHallOfFame", "All-time favourites"},
+		{"/confessions/unsolved", "listUnsolved", "Confessions without an accepted fix"},
+	}
+	for _, l := range listings {
+		b.op(http.MethodGet, l.path, "confessions", l.id, l.summary).
+			params(b.confessionListParams()...).
+			respond(http.StatusOK, list[confession.Confession](b, "Confessions", true)).
+			respond(http.StatusBadRequest, badRequest)
+	}
+
+	b.op(http.MethodGet, "/confessions/random", "confessions", "randomConfession", "A random confession").
+		respond(http.StatusOK, b.ok("The confession", confession.Confession{})).
+		respond(http.StatusNotFound, b.fail("No confessions yet"))
+
+	b.op(http.MethodGet, "/confessions/search", "confessions", "searchConfessions", "Full-text search with optional filters; at least one parameter is required").
+		params(
+			query("q", "Full-text query; results are ranked and highlighted", &Schema{Type: "string"}),
+			query("language", "Exact language", &Schema{Type: "string"}),
+			query("tag", "Tag name", &Schema{Type: "string"}),
+			query("solved", "Only solved or only unsolved confessions", &Schema{Type: "boolean"}),
+		).
+		params(b.confessionListParams()...).
+		respond(http.StatusOK, list[confession.SearchHit](b, "Matches", true)).
+		respond(http.StatusBadRequest, badRequest)
+}
+
+func (b *builder) votes() {
+	id := pathID("Confession ID")
+	kind := pathString("kind", "Reaction kind, see GET /reactions")
+	badRequest := b.fail("Invalid id or reaction kind")
+	notFound := b.fail("Confession not found")
+	limited := b.fail("Voting rate limit exceeded")
+
+	b.op(http.MethodGet, "/confessions/{id}/upvote", "upvotes", "getUpvote", "Whether the caller has upvoted").
+		params(id).
+		respond(http.StatusOK, b.ok("Vote state", UpvoteState{})).
+		respond(http.StatusBadRequest, badRequest)
+
+	b.op(http.MethodPost, "/confessions/{id}/upvote", "upvotes", "upvote", "Upvote once per visitor").
+		params(id).
+		respond(http.StatusOK, b.message("Recorded, or already upvoted")).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusNotFound, notFound).
+		respond(http.StatusTooManyRequests, limited)
+
+	b.op(http.MethodDelete, "/confessions/{id}/upvote", "upvotes", "removeUpvote", "Take an upvote back").
+		params(id).
+		respond(http.StatusOK, b.message("Removed, or not upvoted")).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusNotFound, notFound).
+		respond(http.StatusTooManyRequests, limited)
+
+	b.op(http.MethodPost, "/admin/upvotes/reconcile", "upvotes", "reconcileUpvotes", "Repair stored counters that drifted from the vote rows").
+		params(query("dryRun", "Report without writing", &Schema{Type: "boolean"})).
+		secured(adminOnly).
+		respond(http.StatusOK, b.ok("Reconciliation report", upvote.ReconcileReport{})).
+		respond(http.StatusUnauthorized, b.fail("Admin credentials required"))
+
+	b.op(http.MethodGet, "/reactions", "reactions", "listReactionKinds", "Configured reaction kinds").
+		respond(http.StatusOK, b.ok("Reaction kinds", ReactionKinds{}))
+
+	b.op(http.MethodPost, "/confessions/{id}/reactions/{kind}", "reactions", "react", "React once per kind per visitor").
+		params(id, kind).
+		respond(http.StatusOK, b.message("Recorded, or already reacted")).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusNotFound, notFound).
+		respond(http.StatusTooManyRequests, limited)
+
+	b.op(http.MethodDelete, "/confessions/{id}/reactions/{kind}", "reactions", "removeReaction", "Take a reaction back").
+		params(id, kind).
+		respond(http.StatusOK, b.message("Removed, or not reacted")).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusNotFound, notFound).
+		respond(http.StatusTooManyRequests, limited)
+}
+
+func (b *builder) comments() {
+	id := pathID("Confession ID")
+	badRequest := b.fail("Invalid id or body")
+	notFound := b.fail("Confession or comment not found")
+
+	b.op(http.MethodGet, "/confessions/{id}/comments", "comments", "listComments", "Comment thread; top-level comments are paginated, replies are nested").
+		params(id).
+		params(offsetParams(b.pages.Comments)...).
+		params(envelopeParam).
+		respond(http.StatusOK, list[*comment.Comment](b, "Top-level comments", false)).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusNotFound, notFound)
+
+	b.body(b.op(http.MethodPost, "/confessions/{id}/comments", "comments", "createComment", "Comment on a confession or reply to a comment"), comment.CommentRequest{}).
+		params(id).
+		respond(http.StatusCreated, b.ok("The comment", comment.Comment{})).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusNotFound, notFound).
+		respond(http.StatusTooManyRequests, b.fail("Posting rate limit exceeded"))
+
+	b.body(b.op(http.MethodPut, "/confessions/{id}/accepted-comment", "comments", "acceptComment", "Mark a comment as the fix"), comment.AcceptRequest{}).
+		params(id).
+		secured(authorOrAdmin).
+		respond(http.StatusOK, b.message("Accepted")).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusUnauthorized, b.fail("Neither admin credentials nor the manage token")).
+		respond(http.StatusNotFound, notFound)
+
+	b.op(http.MethodDelete, "/confessions/{id}/accepted-comment", "comments", "clearAcceptedComment", "Unmark the accepted fix").
+		params(id).
+		secured(authorOrAdmin).
+		respond(http.StatusOK, b.message("Cleared")).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusUnauthorized, b.fail("Neither admin credentials nor the manage token")).
+		respond(http.StatusNotFound, notFound)
+
+	b.op(http.MethodPost, "/comments/{id}/upvote", "comments", "upvoteComment", "Upvote a comment once per visitor").
+		params(pathID("Comment ID")).
+		respond(http.StatusOK, b.message("Recorded, or already upvoted")).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusNotFound, notFound).
+		respond(http.StatusTooManyRequests, b.fail("Voting rate limit exceeded"))
+
+	b.op(http.MethodDelete, "/comments/{id}", "comments", "deleteComment", "Delete a comment and its replies").
+		params(pathID("Comment ID")).
+		secured(adminOnly).
+		respond(http.StatusOK, b.message("Deleted")).
+		respond(http.StatusBadRequest, badRequest).
+		respond(http.StatusUnauthorized, b.fail("Admin credentials required")).
+		respond(http.StatusNotFound, notFound)
+}
+
+func (b *builder) tags() {
+	b.op(http.MethodGet, "/tags", "tags", "listTags", "List tags; every tag unless paging parameters are given").
+		params(offsetParams(b.pages.Tags)...).
+		params(envelopeParam).
+		respond(http.StatusOK, list[tag.Tag](b, "Tags", false))
+
+	b.body(b.op(http.MethodPost, "/tags", "tags", "createTag", "Create a tag"), tag.TagRequest{}).
+		respond(http.StatusOK, &Response{Description: "Created"}).
+		respond(http.StatusBadRequest, b.fail("Invalid body"))
+
+	b.op(http.MethodGet, "/tags/suggest", "tags", "suggestTags", "Tags starting with a prefix").
+		params(Parameter{Name: "query", In: "query", Required: true, Description: "Prefix, case-insensitive", Schema: &Schema{Type: "string", MinLength: ptr(1)}}).
+		respond(http.StatusOK, b.ok("Matching tags", []tag.Tag{})).
+		respond(http.StatusBadRequest, b.fail("Query too short"))
+
+	b.op(http.MethodDelete, "/tags/{id}", "tags", "deleteTag", "Delete a tag").
+		params(pathID("Tag ID")).
+		secured(adminOnly).
+		respond(http.StatusOK, b.message("Deleted")).
+		respond(http.StatusUnauthorized, b.fail("Admin credentials required"))
+}
+
+func (b *builder) moderation() {
+	id := pathID("Confession ID")
+	unauthorized := b.fail("Admin credentials required")
+	notQueued := b.fail("Not in the moderation queue")
+
+	b.body(b.op(http.MethodPost, "/confessions/{id}/report", "moderation", "reportConfession", "Report a confession; enough reports hide it for review"), moderation.ReportRequest{}).
+		params(id).
+		respond(http.StatusCreated, b.message("Report recorded")).
+		respond(http.StatusOK, b.message("Already reported")).
+		respond(http.StatusBadRequest, b.fail("Invalid id or body")).
+		respond(http.StatusNotFound, b.fail("Confession not found")).
+		respond(http.StatusTooManyRequests, b.fail("Posting rate limit exceeded"))
+
+	b.op(http.MethodGet, "/admin/moderation/queue", "moderation", "moderationQueue", "Flagged confessions with their reports").
+		params(offsetParams(b.pages.Moderation)...).
+		secured(adminOnly).
+		respond(http.StatusOK, b.ok("Queue items", []moderation.QueueItem{})).
+		respond(http.StatusUnauthorized, unauthorized)
+
+	b.op(http.MethodPost, "/admin/moderation/{id}/approve", "moderation", "approveConfession", "Publish a flagged confession").
+		params(id).
+		secured(adminOnly).
+		respond(http.StatusOK, b.message("Approved")).
+		respond(http.StatusBadRequest, b.fail("Invalid id")).
+		respond(http.StatusUnauthorized, unauthorized).
+		respond(http.StatusNotFound, notQueued)
+
+	b.op(http.MethodPost, "/admin/moderation/{id}/reject", "moderation", "rejectConfession", "Keep a flagged confession hidden").
+		params(id).
+		secured(adminOnly).
+		respond(http.StatusOK, b.message("Rejected")).
+		respond(http.StatusBadRequest, b.fail("Invalid id")).
+		respond(http.StatusUnauthorized, unauthorized).
+		respond(http.StatusNotFound, notQueued)
+}
+
+func (b *builder) webhooks() {
+	id := pathID("Subscription ID")
+	unauthorized := b.fail("Admin credentials required")
+	notFound := b.fail("Subscription not found")
+
+	b.op(http.MethodGet, "/admin/webhooks", "webhooks", "listWebhooks", "Registered webhooks").
+		secured(adminOnly).
+		respond(http.StatusOK, b.ok("Subscriptions", []webhook.Subscription{})).
+		respond(http.StatusUnauthorized, unauthorized)
+
+	b.body(b.op(http.MethodPost, "/admin/webhooks", "webhooks", "createWebhook", "Register a webhook"), webhook.SubscriptionRequest{}).
+		secured(adminOnly).
+		respond(http.StatusCreated, b.ok("Created; the signing secret is only ever shown here", webhook.CreateSubscriptionResponse{})).
+		respond(http.StatusBadRequest, b.fail("Invalid body")).
+		respond(http.StatusUnauthorized, unauthorized)
+
+	b.op(http.MethodDelete, "/admin/webhooks/{id}", "webhooks", "deleteWebhook", "Remove a webhook").
+		params(id).
+		secured(adminOnly).
+		respond(http.StatusOK, b.message("Deleted")).
+		respond(http.StatusBadRequest, b.fail("Invalid id")).
+		respond(http.StatusUnauthorized, unauthorized).
+		respond(http.StatusNotFound, notFound)
+
+	b.op(http.MethodGet, "/admin/webhooks/{id}/deliveries", "webhooks", "listWebhookDeliveries", "Delivery attempts, newest first").
+		params(id).
+		params(offsetParams(b.pages.Webhooks)...).
+		params(envelopeParam).
+		secured(adminOnly).
+		respond(http.StatusOK, list[webhook.Delivery](b, "Deliveries", false)).
+		respond(http.StatusBadRequest, b.fail("Invalid id")).
+		respond(http.StatusUnauthorized, unauthorized).
+		respond(http.StatusNotFound, notFound)
+}
+
+func (b *builder) feeds() {
+	conditional := []Parameter{
+		{Name: "If-None-Match", In: "header", Description: "ETag of a cached copy", Schema: &Schema{Type: "string"}},
+		{Name: "If-Modified-Since", In: "header", Description: "Last-Modified of a cached copy", Schema: &Schema{Type: "string"}},
+	}
+	atom := text("Atom 1.0 feed of the 20 newest entries", "application/atom+xml")
+	notModified := &Response{Description: "The cached copy is current"}
+
+	b.op(http.MethodGet, "/feeds/latest.atom", "feeds", "latestFeed", "Newest confessions").
+		params(conditional...).
+		respond(http.StatusOK, atom).
+		respond(http.StatusNotModified, notModified)
+
+	b.op(http.MethodGet, "/feeds/trending.rss", "feeds", "trendingFeed", "Trending confessions").
+		params(conditional...).
+		respond(http.StatusOK, text("RSS 2.0 feed", "application/rss+xml")).
+		respond(http.StatusNotModified, notModified)
+
+	b.op(http.MethodGet, "/feeds/tags/{name}.atom", "feeds", "tagFeed", "Newest confessions with a tag").
+		params(pathString("name", "Tag name")).
+		params(conditional...).
+		respond(http.StatusOK, atom).
+		respond(http.StatusNotModified, notModified).
+		respond(http.StatusNotFound, b.fail("Unknown feed format"))
+
+	b.op(http.MethodGet, "/feeds/language/{language}.atom", "feeds", "languageFeed", "Newest confessions in a language").
+		params(pathString("language", "Programming language")).
+		params(conditional...).
+		respond(http.StatusOK, atom).
+		respond(http.StatusNotModified, notModified).
+		respond(http.StatusNotFound, b.fail("Unknown feed format"))
+}
+
+func (b *builder) meta() {
+	b.op(http.MethodGet, "/stream", "stream", "stream", "Live confession.created, confession.deleted and upvote.recorded events").
+		params(
+			query("language", "Only events about this language", &Schema{Type: "string"}),
+			query("tag", "Only events about this tag", &Schema{Type: "string"}),
+		).
+		respond(http.StatusOK, text("Server-Sent Events; a ': ping' comment every 15s keeps idle connections open", "text/event-stream"))
+
+	b.op(http.MethodGet, "/metrics", "meta", "metrics", "Prometheus metrics").
+		secured(adminOnly).
+		respond(http.StatusOK, text("Prometheus text exposition format; admin auth only when METRICS_REQUIRE_ADMIN is set", "text/plain")).
+		respond(http.StatusUnauthorized, b.fail("Admin credentials required"))
+
+	b.op(http.MethodGet, "/healthz", "meta", "healthz", "Liveness probe").
+		respond(http.StatusOK, b.ok("The process is up", health.Status{}))
+
+	b.op(http.MethodGet, "/readyz", "meta", "readyz", "Readiness probe: database reachable and schema migrated").
+		respond(http.StatusOK, b.ok("Ready to serve; status is degraded while migrations allowed by the configuration are pending", health.Status{})).
+		respond(http.StatusServiceUnavailable, b.ok("A check is failing; checks tells which", health.Status{}))
+
+	b.op(http.MethodGet, "/openapi.json", "meta", "openapi", "This document").
+		respond(http.StatusOK, &Response{Description: "OpenAPI 3 document", Content: map[string]MediaType{"application/json": {Schema: &Schema{Type: "object"}}}})
+
+	b.op(http.MethodGet, "/docs", "meta", "docs", "Browsable API documentation").
+		respond(http.StatusOK, text("HTML page rendering /openapi.json", "text/html"))
+}
@@ -0,0 +1,205 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// generator derives component schemas from Go types through their json tags,
+// and from the binding tags gin validates request bodies with, so the spec
+// changes together with the DTOs
+type generator struct {
+	schemas   map[string]*Schema
+	names     map[reflect.Type]string
+	overrides map[reflect.Type]*Schema
+}
+
+func newGenerator() *generator {
+	return &generator{
+		schemas: map[string]*Schema{},
+		names:   map[reflect.Type]string{},
+		overrides: map[reflect.Type]*Schema{
+			reflect.TypeFor[time.Time](): {Type: "string", Format: "date-time"},
+		},
+	}
+}
+
+// override replaces the reflected schema of a type that marshals itself
+func (g *generator) override(t reflect.Type, s *Schema) {
+	g.overrides[t] = s
+}
+
+// schemaOf returns the schema of v's type; named structs become $refs
+func (g *generator) schemaOf(v any) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	if s, ok := g.overrides[t]; ok {
+		cp := *s
+		return &cp
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.schema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64", Minimum: ptr(0.0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.register(t)}
+	}
+	// interfaces: any JSON value
+	return &Schema{}
+}
+
+// register adds a named struct to the components once; the name is reserved
+// before the fields are walked so self-referencing types (comment replies) terminate
+func (g *generator) register(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := componentName(t)
+	if _, taken := g.schemas[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndexByte(t.PkgPath(), '/')+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	g.names[t] = name
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.object(t)
+	return name
+}
+
+// componentName turns Envelope[pkg/confession.SearchHit] into EnvelopeOfSearchHit
+func componentName(t reflect.Type) string {
+	name := t.Name()
+	i := strings.IndexByte(name, '[')
+	if i < 0 {
+		return name
+	}
+	arg := strings.TrimSuffix(name[i+1:], "]")
+	arg = arg[strings.LastIndexByte(arg, '.')+1:]
+	return name[:i] + "Of" + arg
+}
+
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(t, s)
+	return s
+}
+
+// fields adds t's json-visible fields to s, flattening embedded structs the
+// way encoding/json does
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, s)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := g.schema(f.Type)
+		if rules := f.Tag.Get("binding"); rules != "" {
+			if applyBinding(prop, rules) {
+				s.Required = append(s.Required, name)
+			}
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// applyBinding maps the validator rules gin enforces onto schema keywords;
+// rules after "dive" constrain the items of a slice. It reports whether the
+// field is required.
+func applyBinding(s *Schema, rules string) (required bool) {
+	target := s
+	for _, rule := range strings.Split(rules, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = target == s
+		case "dive":
+			if s.Items == nil {
+				return
+			}
+			target = s.Items
+		case "oneof":
+			target.Enum = strings.Fields(value)
+		case "url":
+			target.Format = "uri"
+		case "email":
+			target.Format = "email"
+		case "min", "max":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			limit(target, key == "min", n)
+		}
+	}
+	return
+}
+
+// limit applies a validator min/max the way the validator reads it for the type
+func limit(s *Schema, lower bool, n int) {
+	switch s.Type {
+	case "string":
+		if lower {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	case "array":
+		if lower {
+			s.MinItems = &n
+		} else {
+			s.MaxItems = &n
+		}
+	case "integer", "number":
+		if lower {
+			s.Minimum = ptr(float64(n))
+		} else {
+			s.Maximum = ptr(float64(n))
+		}
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,116 @@
+package pagination
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/gin-gonic/gin"
+)
+
+// EnvelopeMediaType opts into the envelope through content negotiation,
+// the same as passing ?envelope=1
+const EnvelopeMediaType = "application/vnd.mydearbug.v2+json"
+
+// Envelope wraps a page of a listing with what a UI needs to render a pager.
+// Next and Prev are relative URLs, null at either end of the listing.
+type Envelope[T any] struct {
+	Items      []T     `json:"items"`
+	Total      int64   `json:"total"`
+	Offset     int     `json:"offset"`
+	Limit      int     `json:"limit"`
+	Next       *string `json:"next"`
+	Prev       *string `json:"prev"`
+	NextCursor string  `json:"nextCursor,omitempty"`
+}
+
+// Parse reads ?offset= and ?limit=, defaulting and capping the limit to size
+func Parse(c *gin.Context, size config.PageSize) (offset, limit int) {
+	offset, _ = strconv.Atoi(c.Query("offset"))
+	limit, _ = strconv.Atoi(c.Query("limit"))
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = size.DefaultLimit
+	}
+	if limit > size.MaxLimit {
+		limit = size.MaxLimit
+	}
+	return
+}
+
+// Wants reports whether the client asked for the envelope instead of a bare array
+func Wants(c *gin.Context) bool {
+	switch strings.ToLower(c.Query("envelope")) {
+	case "1", "true", "yes":
+		return true
+	}
+	return strings.Contains(c.GetHeader("Accept"), EnvelopeMediaType)
+}
+
+// Offset writes an offset page: RFC 8288 Link header always, the envelope when
+// requested. total < 0 means unknown; then "next" is offered whenever the
+// page is full and "last" is left out.
+func Offset[T any](c *gin.Context, items []T, offset, limit int, total int64, envelope bool) {
+	var next, prev *string
+	links := []string{link(pageURL(c, 0, limit), "first")}
+
+	if offset > 0 {
+		u := pageURL(c, max(offset-limit, 0), limit)
+		prev = &u
+		links = append(links, link(u, "prev"))
+	}
+	if (total < 0 && len(items) == limit) || (total >= 0 && int64(offset+limit) < total) {
+		u := pageURL(c, offset+limit, limit)
+		next = &u
+		links = append(links, link(u, "next"))
+	}
+	if total >= 0 {
+		last := 0
+		if total > 0 {
+			last = int((total - 1) / int64(limit) * int64(limit))
+		}
+		links = append(links, link(pageURL(c, last, limit), "last"))
+	}
+	c.Header("Link", strings.Join(links, ", "))
+
+	if items == nil {
+		items = []T{}
+	}
+	if !envelope {
+		c.JSON(http.StatusOK, items)
+		return
+	}
+	c.JSON(http.StatusOK, Envelope[T]{
+		Items: items, Total: total, Offset: offset, Limit: limit, Next: next, Prev: prev,
+	})
+}
+
+// CursorLink sets the Link header for a keyset page and returns the next URL,
+// or nil on the last page
+func CursorLink(c *gin.Context, nextCursor string) *string {
+	if nextCursor == "" {
+		return nil
+	}
+	q := c.Request.URL.Query()
+	q.Del("offset")
+	q.Set("cursor", nextCursor)
+	u := c.Request.URL.Path + "?" + q.Encode()
+	c.Header("Link", link(u, "next"))
+	return &u
+}
+
+func pageURL(c *gin.Context, offset, limit int) string {
+	q := c.Request.URL.Query()
+	q.Del("cursor")
+	q.Set("offset", strconv.Itoa(offset))
+	q.Set("limit", strconv.Itoa(limit))
+	return c.Request.URL.Path + "?" + q.Encode()
+}
+
+func link(u, rel string) string {
+	return fmt.Sprintf(`<%s>; rel="%s"`, u, rel)
+}
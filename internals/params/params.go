@@ -0,0 +1,21 @@
+// Package params reads the path parameters the controllers share, answering
+// malformed ones with a problem response.
+package params
+
+import (
+	"strconv"
+
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// ID returns the :id path parameter; anything but a positive number is
+// answered with an invalid_id problem and ok is false
+func ID(c *gin.Context) (id uint, ok bool) {
+	n, err := strconv.Atoi(c.Param("id"))
+	if err != nil || n <= 0 {
+		problem.InvalidID(c)
+		return 0, false
+	}
+	return uint(n), true
+}
@@ -0,0 +1,122 @@
+// Package problem writes every API error as RFC 7807 application/problem+json.
+//
+// Clients branch on Code, never on Title or Detail: codes are part of the
+// contract and keep their meaning once released, messages may be reworded.
+package problem
+
+import (
+	"net/http"
+
+	"github.com/Balaji01-4D/shit-happens/internals/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const ContentType = "application/problem+json"
+
+// Stable error codes
+const (
+	CodeBadRequest       = "bad_request"       // a query or path parameter is invalid; see detail
+	CodeInvalidID        = "invalid_id"        // the :id path segment is not a positive integer
+	CodeInvalidBody      = "invalid_body"      // the body is not JSON or a field has the wrong JSON type
+	CodeValidationFailed = "validation_failed" // the body breaks the DTO rules; see errors
+	CodeInvalidCursor    = "invalid_cursor"    // cursor is malformed or belongs to another listing
+	CodeUnknownReaction  = "unknown_reaction"  // the reaction kind is not configured, see GET /reactions
+	CodeParentMismatch   = "parent_mismatch"   // the parent comment is on another confession
+	CodeThreadTooDeep    = "thread_too_deep"   // replying would nest deeper than allowed
+	CodeNotOnConfession  = "not_on_confession" // the accepted comment belongs to another confession
+	CodeUnauthorized     = "unauthorized"      // admin credentials or the manage token are missing
+	CodeNotFound         = "not_found"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal_error"
+)
+
+// Codes lists every code above, for documentation
+var Codes = []string{
+	CodeBadRequest, CodeInvalidID, CodeInvalidBody, CodeValidationFailed, CodeInvalidCursor,
+	CodeUnknownReaction, CodeParentMismatch, CodeThreadTooDeep, CodeNotOnConfession,
+	CodeUnauthorized, CodeNotFound, CodeRateLimited, CodeInternal,
+}
+
+// Problem is an RFC 7807 problem detail with the code and field errors as extensions
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is one failed validation rule of a request body
+type FieldError struct {
+	Field   string `json:"field"` // JSON path, e.g. "title" or "tags[1]"
+	Rule    string `json:"rule"`  // validator tag: required, min, max, oneof, url, type...
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Code + ": " + p.Detail
+	}
+	return p.Code
+}
+
+// New builds a problem; the title is the status text since the type is about:blank
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Write sends p and aborts the rest of the handler chain
+func Write(c *gin.Context, p *Problem) {
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// Respond is Write for the common case without field errors
+func Respond(c *gin.Context, status int, code, detail string) {
+	Write(c, New(status, code, detail))
+}
+
+// BadRequest rejects an invalid query or path parameter
+func BadRequest(c *gin.Context, detail string) {
+	Respond(c, http.StatusBadRequest, CodeBadRequest, detail)
+}
+
+func InvalidID(c *gin.Context) {
+	Respond(c, http.StatusBadRequest, CodeInvalidID, "invalid id")
+}
+
+func NotFound(c *gin.Context, detail string) {
+	Respond(c, http.StatusNotFound, CodeNotFound, detail)
+}
+
+// Internal logs err with the request's context and answers with detail only:
+// what failed, never why
+func Internal(c *gin.Context, err error, detail string) {
+	logging.FromContext(c).Error(detail,
+		"error", err,
+		"method", c.Request.Method,
+		"route", c.FullPath(),
+	)
+	Respond(c, http.StatusInternalServerError, CodeInternal, detail)
+}
+
+func Unauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Basic realm="Restricted"`)
+	Respond(c, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+}
+
+func TooManyRequests(c *gin.Context, detail string) {
+	Respond(c, http.StatusTooManyRequests, CodeRateLimited, detail)
+}
@@ -0,0 +1,127 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+)
+
+// report validation failures under the JSON names clients send, not the Go
+// field names, and add notblank for text that must not be only whitespace
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		_ = v.RegisterValidation("notblank", validators.NotBlank)
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// InvalidBody answers a failed ShouldBindJSON: one field error per broken rule,
+// or invalid_body when the payload is not JSON of the right shape
+func InvalidBody(c *gin.Context, err error) {
+	Write(c, FromBindError(err))
+}
+
+// FromBindError converts a binding error into a 400 problem
+func FromBindError(err error) *Problem {
+	var (
+		invalid validator.ValidationErrors
+		typeErr *json.UnmarshalTypeError
+		syntax  *json.SyntaxError
+	)
+	switch {
+	case errors.As(err, &invalid):
+		p := New(http.StatusBadRequest, CodeValidationFailed, "request body failed validation")
+		for _, fe := range invalid {
+			p.Errors = append(p.Errors, fieldError(fe))
+		}
+		return p
+	case errors.As(err, &typeErr):
+		p := New(http.StatusBadRequest, CodeInvalidBody, "request body has a field of the wrong type")
+		p.Errors = []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: "must be " + jsonType(typeErr.Type),
+		}}
+		return p
+	case errors.As(err, &syntax), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return New(http.StatusBadRequest, CodeInvalidBody, "request body must be a JSON object")
+	}
+	return New(http.StatusBadRequest, CodeInvalidBody, err.Error())
+}
+
+func fieldError(fe validator.FieldError) FieldError {
+	// the namespace starts with the struct name: ConfessionRequest.tags[0]
+	field := fe.Namespace()
+	if _, rest, ok := strings.Cut(field, "."); ok {
+		field = rest
+	}
+	return FieldError{
+		Field:   field,
+		Rule:    fe.Tag(),
+		Param:   fe.Param(),
+		Message: message(fe),
+	}
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "min":
+		return bound(fe, "at least")
+	case "max":
+		return bound(fe, "at most")
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "url":
+		return "must be a valid URL"
+	case "email":
+		return "must be a valid email address"
+	}
+	return "failed the " + fe.Tag() + " rule"
+}
+
+// bound words min/max the way the validator applies them to the field's kind
+func bound(fe validator.FieldError, qualifier string) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s characters long", qualifier, fe.Param())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("must contain %s %s items", qualifier, fe.Param())
+	}
+	return fmt.Sprintf("must be %s %s", qualifier, fe.Param())
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Pointer:
+		return jsonType(t.Elem())
+	}
+	return "a number"
+}
@@ -0,0 +1,142 @@
+package ranking
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultGravity  = 1.8 // Hacker News' value
+	DefaultWindow   = 30 * 24 * time.Hour
+	DefaultInterval = 5 * time.Minute
+)
+
+// Weight is what a single vote cast at the given time is worth now: it starts
+// at 2^-gravity and decays polynomially with the vote's age in hours.
+func Weight(now, at time.Time, gravity float64) float64 {
+	hours := now.Sub(at).Hours()
+	if hours < 0 {
+		hours = 0
+	}
+	return math.Pow(hours+2, -gravity)
+}
+
+// Score is the hot score of a confession: the submission counts as one vote
+// at creation time (as on HN) plus the decayed weight of every upvote, so a
+// post keeps climbing while it receives fresh votes and sinks once they stop.
+func Score(now, created time.Time, votes []time.Time, gravity float64) float64 {
+	score := Weight(now, created, gravity)
+	for _, at := range votes {
+		score += Weight(now, at, gravity)
+	}
+	return score
+}
+
+// Ranker periodically recomputes confessions.hot_score. Votes and posts older
+// than Window are ignored: their weight has decayed to practically zero.
+type Ranker struct {
+	db      *gorm.DB
+	gravity float64
+	window  time.Duration
+}
+
+func NewRanker(db *gorm.DB, gravity float64, window time.Duration) *Ranker {
+	if gravity <= 0 {
+		gravity = DefaultGravity
+	}
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Ranker{db: db, gravity: gravity, window: window}
+}
+
+// rows per UPDATE when writing the scores back
+const updateBatch = 500
+
+// Recompute rescores every confession with activity inside the window and
+// resets the rest to zero, atomically. It returns the number of scored rows.
+func (r *Ranker) Recompute(now time.Time) (int, error) {
+	since := now.Add(-r.window)
+	scores := map[uint]float64{}
+
+	// votes are streamed and summed per confession, never held all at once
+	for _, table := range []string{"confessions", "upvotes"} {
+		column := "confession_id"
+		if table == "confessions" {
+			column = "id"
+		}
+		rows, err := r.db.Table(table).Select(column, "created_at").Where("created_at >= ?", since).Rows()
+		if err != nil {
+			return 0, err
+		}
+		for rows.Next() {
+			var id uint
+			var at time.Time
+			if err := rows.Scan(&id, &at); err != nil {
+				rows.Close()
+				return 0, err
+			}
+			scores[id] += Weight(now, at, r.gravity)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	ids := slices.Sorted(maps.Keys(scores))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("confessions").
+			Where("hot_score <> ?", 0).
+			UpdateColumn("hot_score", 0).Error; err != nil {
+			return err
+		}
+		for batch := range slices.Chunk(ids, updateBatch) {
+			cases := make([]string, 0, len(batch))
+			args := make([]any, 0, 2*len(batch))
+			for _, id := range batch {
+				// typed, or PostgreSQL resolves the bare parameter as text
+				cases = append(cases, "WHEN ? THEN CAST(? AS DOUBLE PRECISION)")
+				args = append(args, id, scores[id])
+			}
+			if err := tx.Table("confessions").
+				Where("id IN ?", batch).
+				UpdateColumn("hot_score", gorm.Expr("CASE id "+strings.Join(cases, " ")+" END", args...)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(scores), nil
+}
+
+// Run recomputes immediately and then every interval until ctx is done
+func (r *Ranker) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Recompute(time.Now()); err != nil {
+			slog.Error("hot ranking: recompute failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
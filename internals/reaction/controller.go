@@ -0,0 +1,86 @@
+package reaction
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/middleware"
+	"github.com/Balaji01-4D/shit-happens/internals/params"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
+	"github.com/Balaji01-4D/shit-happens/internals/upvote"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func parseTarget(c *gin.Context) (id uint, kind string, ok bool) {
+	if id, ok = params.ID(c); !ok {
+		return 0, "", false
+	}
+	return id, strings.ToLower(c.Param("kind")), true
+}
+
+func reactionError(c *gin.Context, err error, msg string) {
+	switch err {
+	case ErrUnknownKind:
+		problem.Respond(c, http.StatusBadRequest, problem.CodeUnknownReaction, err.Error())
+	case gorm.ErrRecordNotFound:
+		problem.NotFound(c, "confession not found")
+	default:
+		problem.Internal(c, err, msg)
+	}
+}
+
+func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	repo := NewRepo(db)
+	svc := NewService(repo, cfg.Reactions.Kinds)
+
+	// the reactions this deployment offers
+	r.GET("/reactions", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"kinds": svc.Kinds()})
+	})
+
+	r.POST("/confessions/:id/reactions/:kind", middleware.UpvoteRateLimitMiddleware(cfg.RateLimit.Votes), func(c *gin.Context) {
+		id, kind, ok := parseTarget(c)
+		if !ok {
+			return
+		}
+		// same visitor identity and dedupe as upvotes
+		ipHash, clientHash := upvote.VoterHashes(c)
+
+		if repo.HasReacted(c.Request.Context(), id, kind, ipHash, clientHash) {
+			c.JSON(http.StatusOK, gin.H{"message": "already reacted"})
+			return
+		}
+
+		if err := svc.React(c.Request.Context(), id, kind, ipHash, clientHash); err != nil {
+			// Possible race: re-check; if now present, treat as idempotent success
+			if err != ErrUnknownKind && err != gorm.ErrRecordNotFound && repo.HasReacted(c.Request.Context(), id, kind, ipHash, clientHash) {
+				c.JSON(http.StatusOK, gin.H{"message": "already reacted"})
+				return
+			}
+			reactionError(c, err, "failed to react")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "reaction recorded"})
+	})
+
+	r.DELETE("/confessions/:id/reactions/:kind", middleware.UpvoteRateLimitMiddleware(cfg.RateLimit.Votes), func(c *gin.Context) {
+		id, kind, ok := parseTarget(c)
+		if !ok {
+			return
+		}
+		ipHash, clientHash := upvote.VoterHashes(c)
+
+		removed, err := svc.Unreact(c.Request.Context(), id, kind, ipHash, clientHash)
+		if err != nil {
+			reactionError(c, err, "failed to remove reaction")
+			return
+		}
+		if !removed {
+			c.JSON(http.StatusOK, gin.H{"message": "not reacted"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "reaction removed"})
+	})
+}
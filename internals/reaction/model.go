@@ -0,0 +1,17 @@
+package reaction
+
+import "time"
+
+// Reaction is a single visitor's reaction of one kind to a confession.
+//
+// Like upvote.Upvote, (confession_id, kind, ip_hash) and
+// (confession_id, kind, client_hash) are unique, so a visitor can leave each
+// kind of reaction once per confession.
+type Reaction struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ConfessionID uint      `gorm:"uniqueIndex:idx_reaction_conf_kind_ip;uniqueIndex:idx_reaction_conf_kind_client" json:"confessionId"`
+	Kind         string    `gorm:"size:20;uniqueIndex:idx_reaction_conf_kind_ip;uniqueIndex:idx_reaction_conf_kind_client" json:"kind"`
+	IPHash       string    `gorm:"size:64;uniqueIndex:idx_reaction_conf_kind_ip" json:"-"`
+	ClientHash   string    `gorm:"size:64;uniqueIndex:idx_reaction_conf_kind_client" json:"-"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
@@ -0,0 +1,89 @@
+package reaction
+
+import (
+	"context"
+
+	confession "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/upvote"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository struct {
+	DB *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+func (r *Repository) ConfessionExists(ctx context.Context, id uint) bool {
+	var count int64
+	r.DB.WithContext(ctx).Model(&confession.Confession{}).Where("id = ?", id).Count(&count)
+	return count > 0
+}
+
+// byVoter matches this visitor's reactions of one kind by IP or client hash
+func byVoter(confessionID uint, kind, ipHash, clientHash string) func(*gorm.DB) *gorm.DB {
+	return func(q *gorm.DB) *gorm.DB {
+		return q.Where("confession_id = ? AND kind = ?", confessionID, kind).Scopes(upvote.ByVoter(ipHash, clientHash))
+	}
+}
+
+func (r *Repository) HasReacted(ctx context.Context, confessionID uint, kind, ipHash, clientHash string) bool {
+	var reaction Reaction
+	err := r.DB.WithContext(ctx).Scopes(byVoter(confessionID, kind, ipHash, clientHash)).First(&reaction).Error
+	return err == nil
+}
+
+// Save records the reaction and bumps the per-kind counter in one transaction
+func (r *Repository) Save(ctx context.Context, reaction *Reaction) error {
+	tx := r.DB.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	// Rely on unique indexes; a duplicate insert fails before the counter moves
+	if err := tx.Create(reaction).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	count := confession.ReactionCount{ConfessionID: reaction.ConfessionID, Kind: reaction.Kind, Count: 1}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "confession_id"}, {Name: "kind"}},
+		DoUpdates: clause.Assignments(map[string]any{"count": gorm.Expr("reaction_counts.count + 1")}),
+	}).Create(&count).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// Remove deletes the visitor's reaction and lowers the counter in the same
+// transaction. It reports whether there was a reaction to remove.
+func (r *Repository) Remove(ctx context.Context, confessionID uint, kind, ipHash, clientHash string) (bool, error) {
+	tx := r.DB.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return false, err
+	}
+
+	res := tx.Scopes(byVoter(confessionID, kind, ipHash, clientHash)).Delete(&Reaction{})
+	if res.Error != nil {
+		tx.Rollback()
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+
+	if err := tx.Model(&confession.ReactionCount{}).
+		Where("confession_id = ? AND kind = ?", confessionID, kind).
+		UpdateColumn("count", gorm.Expr("CASE WHEN count > ? THEN count - ? ELSE 0 END", res.RowsAffected, res.RowsAffected)).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit().Error
+}
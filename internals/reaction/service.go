@@ -0,0 +1,62 @@
+package reaction
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/Balaji01-4D/shit-happens/internals/tracing"
+	"gorm.io/gorm"
+)
+
+var ErrUnknownKind = errors.New("unknown reaction")
+
+type Service struct {
+	repo  *Repository
+	kinds []string
+}
+
+func NewService(r *Repository, kinds []string) *Service {
+	return &Service{repo: r, kinds: kinds}
+}
+
+// Kinds is the configured set of reactions
+func (s *Service) Kinds() []string {
+	return s.kinds
+}
+
+func (s *Service) check(ctx context.Context, confessionID uint, kind string) error {
+	if !slices.Contains(s.kinds, kind) {
+		return ErrUnknownKind
+	}
+	if !s.repo.ConfessionExists(ctx, confessionID) {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *Service) React(ctx context.Context, confessionID uint, kind, ipHash, clientHash string) error {
+	ctx, span := tracing.Start(ctx, "reaction.Service.React")
+	defer span.End()
+	if err := s.check(ctx, confessionID, kind); err != nil {
+		return err
+	}
+	return s.repo.Save(ctx, &Reaction{
+		ConfessionID: confessionID,
+		Kind:         kind,
+		IPHash:       ipHash,
+		ClientHash:   clientHash,
+		CreatedAt:    time.Now(),
+	})
+}
+
+// Unreact takes back the visitor's reaction; removed is false if there was none
+func (s *Service) Unreact(ctx context.Context, confessionID uint, kind, ipHash, clientHash string) (removed bool, err error) {
+	ctx, span := tracing.Start(ctx, "reaction.Service.Unreact")
+	defer span.End()
+	if err := s.check(ctx, confessionID, kind); err != nil {
+		return false, err
+	}
+	return s.repo.Remove(ctx, confessionID, kind, ipHash, clientHash)
+}
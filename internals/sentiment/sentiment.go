@@ -0,0 +1,115 @@
+package sentiment
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Labels stored in Confession.Sentiment
+const (
+	Positive = "positive"
+	Negative = "negative"
+	Neutral  = "neutral"
+)
+
+// Analyzer classifies free text as Positive, Negative or Neutral.
+// Implementations must be safe for concurrent use.
+type Analyzer interface {
+	Analyze(text string) string
+}
+
+// IsValid reports whether label is one of the known sentiment labels
+func IsValid(label string) bool {
+	return label == Positive || label == Negative || label == Neutral
+}
+
+// Lexicon is an offline word-list analyzer: every known word adds its weight,
+// a preceding negation ("not", "never", "doesn't" ...) flips it.
+type Lexicon struct {
+	words     map[string]int
+	negations map[string]struct{}
+}
+
+func NewLexicon() *Lexicon {
+	return NewLexiconFrom(defaultWords)
+}
+
+// NewLexiconFrom builds a Lexicon from custom word weights (positive > 0, negative < 0)
+func NewLexiconFrom(words map[string]int) *Lexicon {
+	negations := make(map[string]struct{}, len(defaultNegations))
+	for _, n := range defaultNegations {
+		negations[n] = struct{}{}
+	}
+	return &Lexicon{words: words, negations: negations}
+}
+
+// Score returns the summed weight of the text; the sign decides the label
+func (l *Lexicon) Score(text string) int {
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	score := 0
+	negated := false
+	for _, tok := range tokens {
+		tok = strings.Trim(tok, "'")
+		if _, ok := l.negations[tok]; ok {
+			negated = true
+			continue
+		}
+		if w, ok := l.words[tok]; ok {
+			if negated {
+				w = -w
+			}
+			score += w
+		}
+		negated = false
+	}
+	return score
+}
+
+func (l *Lexicon) Analyze(text string) string {
+	switch score := l.Score(text); {
+	case score > 0:
+		return Positive
+	case score < 0:
+		return Negative
+	default:
+		return Neutral
+	}
+}
+
+var defaultNegations = []string{
+	"not", "no", "never", "without", "cannot", "can't", "cant",
+	"don't", "dont", "doesn't", "doesnt", "didn't", "didnt",
+	"isn't", "isnt", "wasn't", "wasnt", "won't", "wont", "aren't",
+}
+
+// developer flavoured word list; weights 1 (mild) to 3 (strong).
+// Plain technical nouns ("bug", "error", "nil") are left out on purpose:
+// every confession mentions them, so they say nothing about the mood.
+var defaultWords = map[string]int{
+	// positive
+	"fixed": 2, "fix": 1, "solved": 2, "resolved": 2, "works": 1, "working": 1, "work": 1,
+	"finally": 1, "success": 2, "successful": 2, "clean": 1, "fast": 1, "faster": 1,
+	"improved": 2, "improvement": 1, "love": 3, "loved": 3, "great": 2, "good": 1,
+	"nice": 1, "awesome": 3, "amazing": 3, "happy": 2, "glad": 2, "thanks": 2,
+	"thank": 2, "learned": 1, "lesson": 1, "elegant": 2, "simple": 1, "easy": 1,
+	"win": 2, "proud": 2, "relief": 2, "relieved": 2, "passing": 1, "passes": 1,
+	"green": 1, "stable": 1, "helpful": 2, "cool": 1, "fun": 2, "best": 2,
+
+	// negative
+	"broken": -2, "break": -1, "breaks": -1, "broke": -2,
+	"crash": -2, "crashed": -2, "crashes": -2, "panic": -2, "panics": -2,
+	"fail": -2, "failed": -2, "fails": -2, "failing": -2,
+	"failure": -2, "wrong": -1, "leak": -2, "leaks": -2, "leaking": -2,
+	"deadlock": -2, "slow": -1, "hang": -2, "hangs": -2, "stuck": -2,
+	"corrupt": -3, "corrupted": -3, "lost": -2, "outage": -3, "down": -1,
+	"hate": -3, "hated": -3, "angry": -3, "rage": -3, "furious": -3, "annoying": -2,
+	"annoyed": -2, "frustrating": -2, "frustrated": -2, "terrible": -3, "awful": -3,
+	"horrible": -3, "nightmare": -3, "painful": -2, "pain": -2, "sad": -2, "cry": -2,
+	"cried": -2, "worst": -3, "bad": -2, "ugly": -2, "stupid": -2, "dumb": -2,
+	"wtf": -3, "cursed": -2, "hell": -2, "impossible": -2, "facepalm": -2,
+	"mistake": -2, "oops": -1, "regret": -2, "blame": -1, "flaky": -2, "weird": -1,
+	"confusing": -2, "confused": -1,
+}
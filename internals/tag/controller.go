@@ -5,45 +5,64 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/Balaji01-4D/shit-happens/config"
 	"github.com/Balaji01-4D/shit-happens/internals/middleware"
+	"github.com/Balaji01-4D/shit-happens/internals/pagination"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func RegisterRoutes(r *gin.Engine, db *gorm.DB) {
+func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	repo := NewRepo(db)
 	service := NewService(repo)
 
 	tagRoutes := r.Group("/tags")
 
 	tagRoutes.GET("", func(c *gin.Context) {
-		tags, err := service.GetTags()
+		envelope := pagination.Wants(c)
+		_, hasLimit := c.GetQuery("limit")
+		_, hasOffset := c.GetQuery("offset")
+
+		// without paging parameters every tag is returned, as before
+		if !envelope && !hasLimit && !hasOffset {
+			tags, err := service.GetTags(c.Request.Context(), 0, 0)
+			if err != nil {
+				problem.Internal(c, err, "failed to fetch tags")
+				return
+			}
+			c.JSON(http.StatusOK, tags)
+			return
+		}
 
+		offset, limit := pagination.Parse(c, cfg.Pagination.Tags)
+		tags, err := service.GetTags(c.Request.Context(), offset, limit)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB query failed"})
+			problem.Internal(c, err, "failed to fetch tags")
 			return
 		}
 
-		c.JSON(http.StatusOK, tags)
+		total := int64(-1)
+		if envelope {
+			if total, err = service.CountTags(c.Request.Context()); err != nil {
+				problem.Internal(c, err, "failed to fetch tags")
+				return
+			}
+		}
+		pagination.Offset(c, tags, offset, limit, total, envelope)
 	})
 
 	tagRoutes.POST("", func(c *gin.Context) {
 
-		var dto struct {
-			Name string `json:"name" binding:"required,min=1,max=50"`
-		}
+		var dto TagRequest
 
 		if err := c.ShouldBindJSON(&dto); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": err.Error(),
-			})
+			problem.InvalidBody(c, err)
 			return
 		}
 
-		if err := service.CreateTag(dto.Name); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "cannot save tag",
-			})
+		if err := service.CreateTag(c.Request.Context(), dto.Name); err != nil {
+			problem.Internal(c, err, "cannot save tag")
 			return
 		}
 
@@ -55,14 +74,14 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB) {
 		query := strings.ToLower(c.Query("query"))
 
 		if len(query) < 1 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Query too short"})
+			problem.BadRequest(c, "Query too short")
 			return
 		}
 
-		tagResult, err := service.SuggestTags(query)
+		tagResult, err := service.SuggestTags(c.Request.Context(), query)
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB query failed"})
+			problem.Internal(c, err, "failed to fetch tags")
 
 			return
 		}
@@ -71,11 +90,11 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB) {
 
 	})
 
-	tagRoutes.DELETE("/:id", middleware.AdminAuthMiddleware(), func(c *gin.Context) {
+	tagRoutes.DELETE("/:id", middleware.AdminAuthMiddleware(cfg.Admin), func(c *gin.Context) {
 		id, _ := strconv.Atoi(c.Param("id"))
 
-		if err := service.DeleteTags(id); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to delete the tag"})
+		if err := service.DeleteTags(c.Request.Context(), id); err != nil {
+			problem.Internal(c, err, "unable to delete the tag")
 			return
 		}
 
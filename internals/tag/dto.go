@@ -0,0 +1,5 @@
+package tag
+
+type TagRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=50"`
+}
@@ -1,6 +1,9 @@
 package tag
 
 import (
+	"context"
+
+	"github.com/Balaji01-4D/shit-happens/internals/dialect"
 	"gorm.io/gorm"
 )
 
@@ -12,22 +15,33 @@ func NewRepo(db *gorm.DB) *Repository {
 	return &Repository{DB: db}
 }
 
-func (r *Repository) Save(tag *Tag) error {
-	return r.DB.Create(tag).Error
+func (r *Repository) Save(ctx context.Context, tag *Tag) error {
+	return r.DB.WithContext(ctx).Create(tag).Error
 }
 
-func (r *Repository) GetTags() ([]Tag, error) {
+// GetTags lists tags by name; limit <= 0 returns all of them
+func (r *Repository) GetTags(ctx context.Context, offset, limit int) ([]Tag, error) {
 	var tags []Tag
-	err := r.DB.Find(&tags).Error
+	db := r.DB.WithContext(ctx).Order("name ASC").Offset(offset)
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	err := db.Find(&tags).Error
 	return tags, err
 }
 
+func (r *Repository) CountTags(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&Tag{}).Count(&n).Error
+	return n, err
+}
+
 
-func (r *Repository) SuggestTags(query string) ([]Tag, error) {
+func (r *Repository) SuggestTags(ctx context.Context, query string) ([]Tag, error) {
 
 	var tags []Tag
-	err := r.DB.
-		Where("name ILIKE ?", query+"%").
+	err := r.DB.WithContext(ctx).
+		Where("name "+dialect.ILike(r.DB)+" ?", query+"%").
 		Order("name ASC").
 		Limit(6).
 		Find(&tags).Error;
@@ -37,8 +51,8 @@ func (r *Repository) SuggestTags(query string) ([]Tag, error) {
 }
 
 
-func (r *Repository) DeleteTags(id int) error {
-	tx := r.DB.Begin()
+func (r *Repository) DeleteTags(ctx context.Context, id int) error {
+	tx := r.DB.WithContext(ctx).Begin()
 
 	if err := tx.Error; err != nil {
 		return err
@@ -59,8 +73,8 @@ func (r *Repository) DeleteTags(id int) error {
 	return tx.Commit().Error
 }
 
-func (r *Repository) GetTagByName(name string) (Tag, error) {
+func (r *Repository) GetTagByName(ctx context.Context, name string) (Tag, error) {
 	var tag Tag
-	err := r.DB.Where("name = ?", name).First(&tag).Error
+	err := r.DB.WithContext(ctx).Where("name = ?", name).First(&tag).Error
 	return tag, err
 }
\ No newline at end of file
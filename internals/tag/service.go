@@ -1,5 +1,11 @@
 package tag
 
+import (
+	"context"
+
+	"github.com/Balaji01-4D/shit-happens/internals/tracing"
+)
+
 type Service struct {
 	repo *Repository
 }
@@ -8,29 +14,44 @@ func NewService(r *Repository) *Service {
 	return &Service{repo: r}
 }
 
-func (s *Service) CreateTag(name string) error {
-
+func (s *Service) CreateTag(ctx context.Context, name string) error {
+	ctx, span := tracing.Start(ctx, "tag.Service.CreateTag")
+	defer span.End()
 	tag := Tag{
 		Name: name,
 	}
 
-	return  s.repo.Save(&tag)
+	return  s.repo.Save(ctx, &tag)
 }
 
 
-func (s *Service) SuggestTags(query string) ([]Tag, error) {
-	return s.repo.SuggestTags(query)
+func (s *Service) SuggestTags(ctx context.Context, query string) ([]Tag, error) {
+	ctx, span := tracing.Start(ctx, "tag.Service.SuggestTags")
+	defer span.End()
+	return s.repo.SuggestTags(ctx, query)
 }
 
-func (s *Service) DeleteTags(id int) error {
-	return s.repo.DeleteTags(id)
+func (s *Service) DeleteTags(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "tag.Service.DeleteTags")
+	defer span.End()
+	return s.repo.DeleteTags(ctx, id)
 }
 
 
-func (s *Service) GetTags() ([]Tag, error) {
-	return s.repo.GetTags()
+func (s *Service) GetTags(ctx context.Context, offset, limit int) ([]Tag, error) {
+	ctx, span := tracing.Start(ctx, "tag.Service.GetTags")
+	defer span.End()
+	return s.repo.GetTags(ctx, offset, limit)
+}
+
+func (s *Service) CountTags(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "tag.Service.CountTags")
+	defer span.End()
+	return s.repo.CountTags(ctx)
 }
 
-func (s *Service) GetTagByName(name string) (Tag, error) {
-	return s.repo.GetTagByName(name)
+func (s *Service) GetTagByName(ctx context.Context, name string) (Tag, error) {
+	ctx, span := tracing.Start(ctx, "tag.Service.GetTagByName")
+	defer span.End()
+	return s.repo.GetTagByName(ctx, name)
 }
\ No newline at end of file
@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	spanKey   = "tracing:span"
+	parentKey = "tracing:parent"
+)
+
+// inStatement marks a context as carrying a GORM statement span, so the
+// statements GORM runs on its own inside it are recognised as preloads
+type inStatement struct{}
+
+// GormPlugin opens a span per statement as a child of the statement's context
+// (see gorm.DB.WithContext); install it with db.Use. Preload queries run
+// inside the parent query and show up as "gorm.preload <table>" children.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string { return "tracing" }
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("tracing:before_create", start("create")),
+		cb.Create().After("*").Register("tracing:after_create", end),
+		cb.Query().Before("*").Register("tracing:before_query", start("query")),
+		cb.Query().After("*").Register("tracing:after_query", end),
+		cb.Update().Before("*").Register("tracing:before_update", start("update")),
+		cb.Update().After("*").Register("tracing:after_update", end),
+		cb.Delete().Before("*").Register("tracing:before_delete", start("delete")),
+		cb.Delete().After("*").Register("tracing:after_delete", end),
+		cb.Row().Before("*").Register("tracing:before_row", start("row")),
+		cb.Row().After("*").Register("tracing:after_row", end),
+		cb.Raw().Before("*").Register("tracing:before_raw", start("raw")),
+		cb.Raw().After("*").Register("tracing:after_raw", end),
+	)
+}
+
+func start(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		parent := db.Statement.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+		name := "gorm." + operation
+		if operation == "query" && parent.Value(inStatement{}) != nil {
+			name = "gorm.preload"
+		}
+		if table := db.Statement.Table; table != "" {
+			name += " " + table
+		}
+
+		ctx, span := Start(parent, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(db.Dialector.Name()),
+				semconv.DBOperationName(operation),
+			),
+		)
+		db.InstanceSet(spanKey, span)
+		db.InstanceSet(parentKey, parent)
+		db.Statement.Context = context.WithValue(ctx, inStatement{}, true)
+	}
+}
+
+func end(db *gorm.DB) {
+	v, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := v.(trace.Span)
+	if parent, ok := db.InstanceGet(parentKey); ok {
+		db.Statement.Context = parent.(context.Context)
+	}
+
+	span.SetAttributes(
+		semconv.DBCollectionName(db.Statement.Table),
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	// a missing row is an answer, not a failure
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
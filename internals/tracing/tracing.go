@@ -0,0 +1,75 @@
+// Package tracing sets up OpenTelemetry tracing: the exporter, W3C trace
+// context propagation, a span per HTTP request and per GORM statement.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentation = "github.com/Balaji01-4D/shit-happens"
+	serviceName     = "mydearbug"
+)
+
+// Start opens a span named after the operation, e.g. "confession.Service.Get",
+// as a child of whatever span ctx carries
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, opts...)
+}
+
+// Setup installs the global tracer provider and the W3C traceparent/baggage
+// propagator. exporterName, the tracing.exporter setting, picks the exporter:
+//
+//   - "otlp" sends spans over OTLP/HTTP to OTEL_EXPORTER_OTLP_ENDPOINT
+//     (http://localhost:4318 by default)
+//   - "stdout" (or "console") pretty-prints them, for local runs
+//   - "none" or empty records nothing; incoming trace IDs are still propagated
+//
+// The returned function flushes pending spans and must be called on exit.
+func Setup(ctx context.Context, exporterName string) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch kind := strings.ToLower(strings.TrimSpace(exporterName)); kind {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "stdout", "console":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
@@ -7,69 +7,132 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/events"
 	middleware "github.com/Balaji01-4D/shit-happens/internals/middleware"
+	"github.com/Balaji01-4D/shit-happens/internals/params"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func RegisterRoutes(r *gin.Engine, db *gorm.DB) {
-	repo := NewRepo(db)
-	svc := NewService(repo)
-
-	// upvote the confession
-	r.POST("/confessions/:id/upvote", middleware.UpvoteRateLimitMiddleware(), func(c *gin.Context) {
-		id, _ := strconv.Atoi(c.Param("id"))
-		ip := c.ClientIP()
+// VoterHashes identifies an anonymous voter by the SHA-256 of the client IP and
+// of a long-lived random client id cookie, which is issued on first use.
+// Either hash is a dedupe key for votes.
+func VoterHashes(c *gin.Context) (ipHash, clientHash string) {
+	ip := c.ClientIP()
 
-		hash := sha256.Sum256([]byte(ip))
-		ipHash := hex.EncodeToString(hash[:])
+	hash := sha256.Sum256([]byte(ip))
+	ipHash = hex.EncodeToString(hash[:])
 
-		// Read or set a long-lived anonymous client id cookie
-		const cookieName = "mdb_client_id"
-		clientID, err := c.Cookie(cookieName)
-		if err != nil || clientID == "" {
-			// generate 16 random bytes hex
-			buf := make([]byte, 16)
-			if _, rerr := rand.Read(buf); rerr == nil {
-				clientID = hex.EncodeToString(buf)
-				maxAge := 60 * 60 * 24 * 365 // 1 year expiry
-				// Detect HTTPS (direct or via proxy)
-				secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
-				// Set cookie with SameSite=None for cross-site usage when secure
-				ck := &http.Cookie{
-					Name:     cookieName,
-					Value:    clientID,
-					Path:     "/",
-					MaxAge:   maxAge,
-					HttpOnly: true,
-					Secure:   secure,
-				}
-				if secure {
-					ck.SameSite = http.SameSiteNoneMode
-				}
-				http.SetCookie(c.Writer, ck)
+	// Read or set a long-lived anonymous client id cookie
+	const cookieName = "mdb_client_id"
+	clientID, err := c.Cookie(cookieName)
+	if err != nil || clientID == "" {
+		// generate 16 random bytes hex
+		buf := make([]byte, 16)
+		if _, rerr := rand.Read(buf); rerr == nil {
+			clientID = hex.EncodeToString(buf)
+			maxAge := 60 * 60 * 24 * 365 // 1 year expiry
+			// Detect HTTPS (direct or via proxy)
+			secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+			// Set cookie with SameSite=None for cross-site usage when secure
+			ck := &http.Cookie{
+				Name:     cookieName,
+				Value:    clientID,
+				Path:     "/",
+				MaxAge:   maxAge,
+				HttpOnly: true,
+				Secure:   secure,
+			}
+			if secure {
+				ck.SameSite = http.SameSiteNoneMode
 			}
+			http.SetCookie(c.Writer, ck)
 		}
-		clientHash := ""
-		if clientID != "" {
-			ch := sha256.Sum256([]byte(clientID))
-			clientHash = hex.EncodeToString(ch[:])
+	}
+	if clientID != "" {
+		ch := sha256.Sum256([]byte(clientID))
+		clientHash = hex.EncodeToString(ch[:])
+	}
+	return ipHash, clientHash
+}
+
+func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	repo := NewRepo(db)
+	svc := NewService(repo, events.Default)
+
+	// upvote the confession
+	r.POST("/confessions/:id/upvote", middleware.UpvoteRateLimitMiddleware(cfg.RateLimit.Votes), func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
 		}
+		ipHash, clientHash := VoterHashes(c)
 
-		if repo.HasUpvoted(uint(id), ipHash, clientHash) {
+		if repo.HasUpvoted(c.Request.Context(), id, ipHash, clientHash) {
 			c.JSON(http.StatusOK, gin.H{"message": "already upvoted"})
 			return
 		}
 
-		if err := svc.Upvote(uint(id), ipHash, clientHash); err != nil {
+		if err := svc.Upvote(c.Request.Context(), id, ipHash, clientHash); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "confession not found")
+				return
+			}
 			// Possible race: re-check; if now present, treat as idempotent success
-			if repo.HasUpvoted(uint(id), ipHash, clientHash) {
+			if repo.HasUpvoted(c.Request.Context(), id, ipHash, clientHash) {
 				c.JSON(http.StatusOK, gin.H{"message": "already upvoted"})
 				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upvote"})
+			problem.Internal(c, err, "failed to upvote")
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"message": "upvote recorded"})
 	})
+
+	// take back the upvote
+	r.DELETE("/confessions/:id/upvote", middleware.UpvoteRateLimitMiddleware(cfg.RateLimit.Votes), func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		ipHash, clientHash := VoterHashes(c)
+
+		removed, err := svc.Unvote(c.Request.Context(), id, ipHash, clientHash)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "confession not found")
+				return
+			}
+			problem.Internal(c, err, "failed to remove upvote")
+			return
+		}
+		if !removed {
+			c.JSON(http.StatusOK, gin.H{"message": "not upvoted"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "upvote removed"})
+	})
+
+	// whether the current client has upvoted, for rendering the vote toggle
+	r.GET("/confessions/:id/upvote", func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		ipHash, clientHash := VoterHashes(c)
+		c.JSON(http.StatusOK, gin.H{"upvoted": repo.HasUpvoted(c.Request.Context(), id, ipHash, clientHash)})
+	})
+
+	// recompute upvote counters from the vote rows; ?dryRun=true only reports the drift
+	r.POST("/admin/upvotes/reconcile", middleware.AdminAuthMiddleware(cfg.Admin), func(c *gin.Context) {
+		dryRun, _ := strconv.ParseBool(c.Query("dryRun"))
+		report, err := svc.Reconcile(c.Request.Context(), !dryRun)
+		if err != nil {
+			problem.Internal(c, err, "failed to reconcile")
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	})
 }
@@ -1,5 +1,15 @@
 package upvote
 
-type UpvoteDTO struct {
-	ConfessionID uint `json:"confessionId" binding:"required"`
+// Drift is a confession whose stored counter disagrees with its upvote rows
+type Drift struct {
+	ConfessionID uint `json:"confessionId"`
+	Stored       int  `json:"stored"`
+	Actual       int  `json:"actual"`
+}
+
+// ReconcileReport is the outcome of a counter reconciliation run
+type ReconcileReport struct {
+	Drifted []Drift `json:"drifted"`
+	Orphans int64   `json:"orphans"` // votes left behind by deleted confessions
+	Applied bool    `json:"applied"` // false on a dry run
 }
@@ -1,6 +1,11 @@
 package upvote
 
-import "gorm.io/gorm"
+import (
+	"context"
+
+	confession "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"gorm.io/gorm"
+)
 
 type Repository struct {
 	DB *gorm.DB
@@ -10,22 +15,148 @@ func NewRepo(db *gorm.DB) *Repository {
 	return &Repository{DB: db}
 }
 
+// ByVoter matches the rows (votes, reactions, ...) cast from this IP or client
+// hash; either one is enough to count as the same visitor
+func ByVoter(ipHash, clientHash string) func(*gorm.DB) *gorm.DB {
+	return func(q *gorm.DB) *gorm.DB {
+		if ipHash != "" && clientHash != "" {
+			return q.Where("(ip_hash = ? OR client_hash = ?)", ipHash, clientHash)
+		} else if ipHash != "" {
+			return q.Where("ip_hash = ?", ipHash)
+		} else if clientHash != "" {
+			return q.Where("client_hash = ?", clientHash)
+		}
+		return q
+	}
+}
+
+// byVoter matches the votes cast on a confession from this IP or client hash
+func byVoter(confessionID uint, ipHash, clientHash string) func(*gorm.DB) *gorm.DB {
+	return func(q *gorm.DB) *gorm.DB {
+		return q.Where("confession_id = ?", confessionID).Scopes(ByVoter(ipHash, clientHash))
+	}
+}
+
 // Checks whether the user is already upvoted the confessions by IP or client hash
-func (r *Repository) HasUpvoted(confessionID uint, ipHash, clientHash string) bool {
+func (r *Repository) HasUpvoted(ctx context.Context, confessionID uint, ipHash, clientHash string) bool {
 	var upvote Upvote
-	q := r.DB.Where("confession_id = ?", confessionID)
-	if ipHash != "" && clientHash != "" {
-		q = q.Where(r.DB.Where("ip_hash = ?", ipHash).Or("client_hash = ?", clientHash))
-	} else if ipHash != "" {
-		q = q.Where("ip_hash = ?", ipHash)
-	} else if clientHash != "" {
-		q = q.Where("client_hash = ?", clientHash)
-	}
-	err := q.First(&upvote).Error
+	err := r.DB.WithContext(ctx).Scopes(byVoter(confessionID, ipHash, clientHash)).First(&upvote).Error
 	return err == nil
 }
 
-func (r *Repository) Save(upvote *Upvote) error {
+// Remove deletes the voter's upvote and lowers the confession's counter in the
+// same transaction. It reports whether there was a vote to remove.
+func (r *Repository) Remove(ctx context.Context, confessionID uint, ipHash, clientHash string) (bool, error) {
+	tx := r.DB.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return false, err
+	}
+
+	res := tx.Scopes(byVoter(confessionID, ipHash, clientHash)).Delete(&Upvote{})
+	if res.Error != nil {
+		tx.Rollback()
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+
+	if err := tx.Model(&confession.Confession{}).
+		Where("id = ?", confessionID).
+		UpdateColumn("upvotes", gorm.Expr("CASE WHEN upvotes > ? THEN upvotes - ? ELSE 0 END", res.RowsAffected, res.RowsAffected)).Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit().Error
+}
+
+func (r *Repository) ConfessionExists(ctx context.Context, id uint) bool {
+	var count int64
+	r.DB.WithContext(ctx).Model(&confession.Confession{}).Where("id = ?", id).Count(&count)
+	return count > 0
+}
+
+// Confession loads the voted confession with its tags
+func (r *Repository) Confession(ctx context.Context, id uint) (confession.Confession, error) {
+	var c confession.Confession
+	err := r.DB.WithContext(ctx).Preload("Tags").First(&c, id).Error
+	return c, err
+}
+
+// Save records the vote and bumps the counter in one transaction
+func (r *Repository) Save(ctx context.Context, upvote *Upvote) error {
+	tx := r.DB.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
 	// Rely on unique indexes; if a duplicate insert happens, surface the error to caller
-	return r.DB.Create(upvote).Error
+	if err := tx.Create(upvote).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	res := tx.Model(&confession.Confession{}).
+		Where("id = ?", upvote.ConfessionID).
+		UpdateColumn("upvotes", gorm.Expr("upvotes + 1"))
+	if res.Error != nil {
+		tx.Rollback()
+		return res.Error
+	}
+	// the confession was deleted meanwhile; don't leave an orphan vote
+	if res.RowsAffected == 0 {
+		tx.Rollback()
+		return gorm.ErrRecordNotFound
+	}
+
+	return tx.Commit().Error
+}
+
+// Drift lists confessions whose upvotes counter differs from COUNT(*) of their votes
+func (r *Repository) Drift(ctx context.Context) ([]Drift, error) {
+	var drift []Drift
+	err := r.DB.WithContext(ctx).Table("confessions").
+		Select("confessions.id AS confession_id, confessions.upvotes AS stored, COUNT(upvotes.id) AS actual").
+		Joins("LEFT JOIN upvotes ON upvotes.confession_id = confessions.id").
+		Group("confessions.id, confessions.upvotes").
+		Having("confessions.upvotes <> COUNT(upvotes.id)").
+		Order("confessions.id").
+		Scan(&drift).Error
+	return drift, err
+}
+
+func orphaned(db *gorm.DB) *gorm.DB {
+	return db.Where("confession_id NOT IN (?)", db.Session(&gorm.Session{NewDB: true}).Model(&confession.Confession{}).Select("id"))
+}
+
+// CountOrphans counts votes whose confession no longer exists
+func (r *Repository) CountOrphans(ctx context.Context) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&Upvote{}).Scopes(orphaned).Count(&n).Error
+	return n, err
+}
+
+// Reconcile rewrites the drifted counters from the vote rows and drops orphaned votes
+func (r *Repository) Reconcile(ctx context.Context, drift []Drift) error {
+	tx := r.DB.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	for _, d := range drift {
+		if err := tx.Model(&confession.Confession{}).
+			Where("id = ?", d.ConfessionID).
+			UpdateColumn("upvotes", tx.Session(&gorm.Session{NewDB: true}).Model(&Upvote{}).
+				Select("COUNT(*)").Where("confession_id = ?", d.ConfessionID)).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Scopes(orphaned).Delete(&Upvote{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
 }
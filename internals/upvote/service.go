@@ -1,32 +1,96 @@
 package upvote
 
 import (
+	"context"
 	"time"
 
-	confession "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/events"
+	"github.com/Balaji01-4D/shit-happens/internals/metrics"
+	"github.com/Balaji01-4D/shit-happens/internals/tracing"
 	"gorm.io/gorm"
 )
 
 type Service struct {
-	repo *Repository
+	repo      *Repository
+	publisher events.Publisher
 }
 
-func NewService(r *Repository) *Service {
-	return &Service{repo: r}
+// NewService returns the vote service; publisher, when not nil, is told about
+// recorded votes
+func NewService(r *Repository, publisher events.Publisher) *Service {
+	return &Service{repo: r, publisher: publisher}
 }
 
-func (s *Service) Upvote(confessionID uint, ipHash, clientHash string) error {
+// Unvote takes back the voter's upvote; removed is false if there was none
+func (s *Service) Unvote(ctx context.Context, confessionID uint, ipHash, clientHash string) (removed bool, err error) {
+	ctx, span := tracing.Start(ctx, "upvote.Service.Unvote")
+	defer span.End()
+	if !s.repo.ConfessionExists(ctx, confessionID) {
+		return false, gorm.ErrRecordNotFound
+	}
+	return s.repo.Remove(ctx, confessionID, ipHash, clientHash)
+}
+
+func (s *Service) Upvote(ctx context.Context, confessionID uint, ipHash, clientHash string) error {
+	ctx, span := tracing.Start(ctx, "upvote.Service.Upvote")
+	defer span.End()
+	if !s.repo.ConfessionExists(ctx, confessionID) {
+		return gorm.ErrRecordNotFound
+	}
 	now := time.Now()
 	up := &Upvote{ConfessionID: confessionID, IPHash: ipHash, ClientHash: clientHash, CreatedAt: now}
-	if err := s.repo.Save(up); err != nil {
-		// If insert fails (likely due to unique constraint), do not bump counter
-		return err
-	}
-	// Only bump the confession's upvote count after successful insert
-	if err := s.repo.DB.Model(&confession.Confession{}).
-		Where("id = ?", confessionID).
-		UpdateColumn("upvotes", gorm.Expr("upvotes + 1")).Error; err != nil {
+	// If insert fails (likely due to unique constraint), the counter is not bumped
+	if err := s.repo.Save(ctx, up); err != nil {
 		return err
 	}
+	metrics.UpvotesRecorded.Inc()
+	s.publishVote(ctx, confessionID)
 	return nil
 }
+
+// publishVote announces the confession's new upvote count
+func (s *Service) publishVote(ctx context.Context, confessionID uint) {
+	if s.publisher == nil {
+		return
+	}
+	c, err := s.repo.Confession(ctx, confessionID)
+	if err != nil {
+		return
+	}
+	tags := make([]string, 0, len(c.Tags))
+	for _, t := range c.Tags {
+		tags = append(tags, t.Name)
+	}
+	s.publisher.Publish(events.Event{
+		Type:     events.UpvoteRecorded,
+		Language: c.Language,
+		Tags:     tags,
+		Data:     map[string]any{"confessionId": c.ID, "upvotes": c.Upvotes},
+	})
+}
+
+// Reconcile recomputes confessions.upvotes from the vote rows and reports the
+// drift it found. With apply false it only reports.
+func (s *Service) Reconcile(ctx context.Context, apply bool) (ReconcileReport, error) {
+	ctx, span := tracing.Start(ctx, "upvote.Service.Reconcile")
+	defer span.End()
+	drift, err := s.repo.Drift(ctx)
+	if err != nil {
+		return ReconcileReport{}, err
+	}
+	orphans, err := s.repo.CountOrphans(ctx)
+	if err != nil {
+		return ReconcileReport{}, err
+	}
+	report := ReconcileReport{Drifted: drift, Orphans: orphans}
+	if report.Drifted == nil {
+		report.Drifted = []Drift{}
+	}
+	if apply && (len(drift) > 0 || orphans > 0) {
+		if err := s.repo.Reconcile(ctx, drift); err != nil {
+			return ReconcileReport{}, err
+		}
+	}
+	report.Applied = apply
+	return report, nil
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/middleware"
+	"github.com/Balaji01-4D/shit-happens/internals/pagination"
+	"github.com/Balaji01-4D/shit-happens/internals/params"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	repo := NewRepo(db)
+	svc := NewService(repo)
+
+	adminRoutes := r.Group("/admin/webhooks", middleware.AdminAuthMiddleware(cfg.Admin))
+
+	adminRoutes.GET("", func(c *gin.Context) {
+		subs, err := svc.List(c.Request.Context())
+		if err != nil {
+			problem.Internal(c, err, "failed to fetch webhooks")
+			return
+		}
+		c.JSON(http.StatusOK, subs)
+	})
+
+	adminRoutes.POST("", func(c *gin.Context) {
+		var dto SubscriptionRequest
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			problem.InvalidBody(c, err)
+			return
+		}
+		sub, err := svc.Create(c.Request.Context(), dto)
+		if err != nil {
+			problem.Internal(c, err, "failed to create")
+			return
+		}
+		c.JSON(http.StatusCreated, CreateSubscriptionResponse{Subscription: sub, Secret: sub.Secret})
+	})
+
+	adminRoutes.DELETE("/:id", func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		if err := svc.Delete(c.Request.Context(), id); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "subscription not found")
+				return
+			}
+			problem.Internal(c, err, "failed to delete")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "successfully deleted"})
+	})
+
+	// delivery log, newest attempt first
+	adminRoutes.GET("/:id/deliveries", func(c *gin.Context) {
+		id, ok := params.ID(c)
+		if !ok {
+			return
+		}
+		offset, limit := pagination.Parse(c, cfg.Pagination.Webhooks)
+		deliveries, err := svc.Deliveries(c.Request.Context(), id, offset, limit)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				problem.NotFound(c, "subscription not found")
+				return
+			}
+			problem.Internal(c, err, "failed to fetch deliveries")
+			return
+		}
+		envelope := pagination.Wants(c)
+		total := int64(-1)
+		if envelope {
+			if total, err = svc.CountDeliveries(c.Request.Context(), id); err != nil {
+				problem.Internal(c, err, "failed to count")
+				return
+			}
+		}
+		pagination.Offset(c, deliveries, offset, limit, total, envelope)
+	})
+}
@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Balaji01-4D/shit-happens/internals/events"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultMaxAttempts = 6
+	DefaultBackoff     = 2 * time.Second // before the first retry, doubled after each one
+	// how long Run lets deliveries under way finish once it is cancelled
+	DefaultDrainTimeout = 15 * time.Second
+
+	// events queued for dispatch before the broker starts dropping them
+	dispatchBuffer = 256
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign returns the signature header value: "sha256=" followed by the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the subscription secret.
+// Receivers recompute it and should reject stale timestamps.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher forwards broker events to the matching subscriptions, retrying
+// failed deliveries with exponential backoff and logging every attempt.
+type Dispatcher struct {
+	repo         *Repository
+	broker       *events.Broker
+	client       *http.Client
+	MaxAttempts  int
+	Backoff      time.Duration
+	DrainTimeout time.Duration
+
+	deliveries sync.WaitGroup
+}
+
+func NewDispatcher(db *gorm.DB, broker *events.Broker) *Dispatcher {
+	return &Dispatcher{
+		repo:         NewRepo(db),
+		broker:       broker,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts:  DefaultMaxAttempts,
+		Backoff:      DefaultBackoff,
+		DrainTimeout: DefaultDrainTimeout,
+	}
+}
+
+// Run dispatches events until ctx is cancelled. It then dispatches the events
+// still queued and returns once every delivery is done, abandoning the retries
+// left after DrainTimeout.
+func (d *Dispatcher) Run(ctx context.Context) {
+	sub := d.broker.Subscribe(events.Filter{}, dispatchBuffer)
+	// deliveries outlive ctx so the last events still reach the webhooks
+	deliveryCtx, abandon := context.WithCancel(context.WithoutCancel(ctx))
+	defer abandon()
+
+receive:
+	for {
+		select {
+		case <-ctx.Done():
+			break receive
+		case e, ok := <-sub.C:
+			if !ok {
+				break receive
+			}
+			d.dispatch(deliveryCtx, e)
+		}
+	}
+	d.broker.Unsubscribe(sub)
+	for e := range sub.C {
+		d.dispatch(deliveryCtx, e)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		d.deliveries.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(d.DrainTimeout):
+		abandon()
+		<-drained
+	}
+}
+
+func matches(s Subscription, e events.Event) bool {
+	if len(s.EventTypes) > 0 && !slices.Contains(s.EventTypes, e.Type) {
+		return false
+	}
+	return events.Filter{Language: s.Language, Tag: s.Tag}.Match(e)
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, e events.Event) {
+	subs, err := d.repo.Active(ctx)
+	if err != nil {
+		slog.Error("webhook: loading subscriptions failed, event dropped", "event_id", e.ID, "error", err)
+		return
+	}
+	var body []byte
+	for _, s := range subs {
+		if !matches(s, e) {
+			continue
+		}
+		if body == nil {
+			body, err = json.Marshal(Payload{ID: e.ID, Type: e.Type, CreatedAt: time.Now().UTC(), Data: e.Data})
+			if err != nil {
+				slog.Error("webhook: encoding event failed", "event_id", e.ID, "error", err)
+				return
+			}
+		}
+		d.deliveries.Add(1)
+		go func() {
+			defer d.deliveries.Done()
+			d.deliver(ctx, s, e, body)
+		}()
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, s Subscription, e events.Event, body []byte) {
+	delay := d.Backoff
+	for attempt := 1; attempt <= d.MaxAttempts; attempt++ {
+		if d.attempt(ctx, s, e, body, attempt) || attempt == d.MaxAttempts {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			slog.Warn("webhook: shutting down, retries abandoned", "event_id", e.ID, "subscription_id", s.ID, "attempts", attempt)
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// attempt POSTs the event once and records the outcome; 2xx counts as delivered
+func (d *Dispatcher) attempt(ctx context.Context, s Subscription, e events.Event, body []byte, n int) bool {
+	delivery := Delivery{SubscriptionID: s.ID, EventID: e.ID, EventType: e.Type, Attempt: n, CreatedAt: time.Now()}
+
+	ts := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "MyDearBug-Webhooks/1.0")
+		req.Header.Set(HeaderEvent, e.Type)
+		req.Header.Set(HeaderDelivery, strconv.FormatUint(e.ID, 10))
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(HeaderSignature, Sign(s.Secret, ts, body))
+
+		var resp *http.Response
+		resp, err = d.client.Do(req)
+		if err == nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			delivery.StatusCode = resp.StatusCode
+			delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+			if !delivery.Success {
+				err = fmt.Errorf("unexpected status %s", resp.Status)
+			}
+		}
+	}
+	if err != nil {
+		delivery.Error = truncate(err.Error(), 500)
+	}
+	delivery.DurationMs = time.Since(delivery.CreatedAt).Milliseconds()
+
+	if serr := d.repo.SaveDelivery(ctx, &delivery); serr != nil {
+		slog.Error("webhook: recording delivery failed", "event_id", e.ID, "subscription_id", s.ID, "error", serr)
+	}
+	return delivery.Success
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
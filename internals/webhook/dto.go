@@ -0,0 +1,25 @@
+package webhook
+
+import "time"
+
+type SubscriptionRequest struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=128"` // generated when omitted
+	EventTypes []string `json:"eventTypes" binding:"omitempty,dive,oneof=confession.created confession.deleted upvote.recorded"`
+	Language   string   `json:"language" binding:"omitempty,max=50"`
+	Tag        string   `json:"tag" binding:"omitempty,max=50"`
+}
+
+// CreateSubscriptionResponse shows the signing secret; it is not returned again
+type CreateSubscriptionResponse struct {
+	Subscription
+	Secret string `json:"secret"`
+}
+
+// Payload is the JSON body POSTed to subscribers
+type Payload struct {
+	ID        uint64    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
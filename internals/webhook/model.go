@@ -0,0 +1,34 @@
+package webhook
+
+import "time"
+
+// Subscription is an admin-registered endpoint that receives site events.
+// Empty EventTypes, Language or Tag match everything.
+type Subscription struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	URL        string    `gorm:"size:2048;not null" json:"url"`
+	Secret     string    `gorm:"size:128;not null" json:"-"` // HMAC-SHA256 key for the signature header
+	EventTypes []string  `gorm:"serializer:json" json:"eventTypes"`
+	Language   string    `gorm:"size:50" json:"language,omitempty"`
+	Tag        string    `gorm:"size:50" json:"tag,omitempty"`
+	Active     bool      `gorm:"default:true;index" json:"active"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Delivery is one attempt to POST an event to a subscription
+type Delivery struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint      `gorm:"index;not null" json:"subscriptionId"`
+	EventID        uint64    `json:"eventId"`
+	EventType      string    `gorm:"size:50" json:"eventType"`
+	Attempt        int       `json:"attempt"`
+	StatusCode     int       `json:"statusCode,omitempty"`
+	Error          string    `gorm:"size:500" json:"error,omitempty"`
+	Success        bool      `json:"success"`
+	DurationMs     int64     `json:"durationMs"`
+	CreatedAt      time.Time `gorm:"index" json:"createdAt"`
+}
+
+func (Subscription) TableName() string { return "webhook_subscriptions" }
+
+func (Delivery) TableName() string { return "webhook_deliveries" }
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type Repository struct {
+	DB *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+func (r *Repository) Create(ctx context.Context, sub *Subscription) error {
+	return r.DB.WithContext(ctx).Create(sub).Error
+}
+
+func (r *Repository) List(ctx context.Context) ([]Subscription, error) {
+	var subs []Subscription
+	err := r.DB.WithContext(ctx).Order("id ASC").Find(&subs).Error
+	return subs, err
+}
+
+func (r *Repository) Active(ctx context.Context) ([]Subscription, error) {
+	var subs []Subscription
+	err := r.DB.WithContext(ctx).Where("active = ?", true).Find(&subs).Error
+	return subs, err
+}
+
+func (r *Repository) Get(ctx context.Context, id uint) (Subscription, error) {
+	var sub Subscription
+	err := r.DB.WithContext(ctx).First(&sub, id).Error
+	return sub, err
+}
+
+// Delete removes the subscription together with its delivery log
+func (r *Repository) Delete(ctx context.Context, id uint) error {
+	tx := r.DB.WithContext(ctx).Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("subscription_id = ?", id).Delete(&Delivery{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	res := tx.Delete(&Subscription{}, id)
+	if res.Error != nil {
+		tx.Rollback()
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		tx.Rollback()
+		return gorm.ErrRecordNotFound
+	}
+
+	return tx.Commit().Error
+}
+
+func (r *Repository) SaveDelivery(ctx context.Context, d *Delivery) error {
+	return r.DB.WithContext(ctx).Create(d).Error
+}
+
+// Deliveries returns the delivery log of a subscription, newest first
+func (r *Repository) Deliveries(ctx context.Context, subscriptionID uint, offset, limit int) ([]Delivery, error) {
+	var deliveries []Delivery
+	err := r.DB.WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *Repository) CountDeliveries(ctx context.Context, subscriptionID uint) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&Delivery{}).Where("subscription_id = ?", subscriptionID).Count(&n).Error
+	return n, err
+}
@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/Balaji01-4D/shit-happens/internals/tracing"
+)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(r *Repository) *Service {
+	return &Service{repo: r}
+}
+
+// Create registers a subscription; the secret is generated when not supplied
+func (s *Service) Create(ctx context.Context, dto SubscriptionRequest) (Subscription, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Create")
+	defer span.End()
+	secret := dto.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return Subscription{}, err
+		}
+		secret = hex.EncodeToString(buf)
+	}
+	sub := Subscription{
+		URL:        dto.URL,
+		Secret:     secret,
+		EventTypes: dto.EventTypes,
+		Language:   dto.Language,
+		Tag:        dto.Tag,
+		Active:     true,
+		CreatedAt:  time.Now(),
+	}
+	if sub.EventTypes == nil {
+		sub.EventTypes = []string{}
+	}
+	if err := s.repo.Create(ctx, &sub); err != nil {
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+func (s *Service) List(ctx context.Context) ([]Subscription, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.List")
+	defer span.End()
+	return s.repo.List(ctx)
+}
+
+func (s *Service) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Delete")
+	defer span.End()
+	return s.repo.Delete(ctx, id)
+}
+
+// Deliveries returns a page of the subscription's delivery log, newest first
+func (s *Service) Deliveries(ctx context.Context, subscriptionID uint, offset, limit int) ([]Delivery, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.Deliveries")
+	defer span.End()
+	if _, err := s.repo.Get(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.Deliveries(ctx, subscriptionID, offset, limit)
+}
+
+func (s *Service) CountDeliveries(ctx context.Context, subscriptionID uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "webhook.Service.CountDeliveries")
+	defer span.End()
+	return s.repo.CountDeliveries(ctx, subscriptionID)
+}
@@ -1,33 +1,188 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/comment"
 	bug "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/dialect"
+	"github.com/Balaji01-4D/shit-happens/internals/events"
+	"github.com/Balaji01-4D/shit-happens/internals/feed"
+	"github.com/Balaji01-4D/shit-happens/internals/health"
+	"github.com/Balaji01-4D/shit-happens/internals/logging"
+	"github.com/Balaji01-4D/shit-happens/internals/metrics"
+	"github.com/Balaji01-4D/shit-happens/internals/middleware"
+	"github.com/Balaji01-4D/shit-happens/internals/migrations"
+	"github.com/Balaji01-4D/shit-happens/internals/moderation"
+	"github.com/Balaji01-4D/shit-happens/internals/openapi"
+	"github.com/Balaji01-4D/shit-happens/internals/problem"
+	"github.com/Balaji01-4D/shit-happens/internals/ranking"
+	"github.com/Balaji01-4D/shit-happens/internals/reaction"
 	"github.com/Balaji01-4D/shit-happens/internals/tag"
+	"github.com/Balaji01-4D/shit-happens/internals/tracing"
 	"github.com/Balaji01-4D/shit-happens/internals/upvote"
+	"github.com/Balaji01-4D/shit-happens/internals/webhook"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	cfg := config.Load()
-	db := config.InitDB(cfg)
+	slog.SetDefault(logging.New(os.Stdout, ""))
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("startup failed", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logging.New(os.Stdout, cfg.Log.Level))
+
+	// SIGTERM (or Ctrl-C) starts the shutdown: stop taking requests, let the
+	// in-flight ones finish, then stop the background jobs
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := config.InitDB(cfg)
+	if err != nil {
+		slog.Error("startup failed", "error", err)
+		os.Exit(1)
+	}
+	// refuse to serve an outdated schema unless told otherwise
+	migrator, err := migrations.New(db)
+	if err == nil {
+		err = migrator.Check(ctx)
+	}
+	if errors.Is(err, migrations.ErrPending) && cfg.Database.AllowPendingMigrations {
+		slog.Warn("starting with pending migrations", "error", err)
+	} else if err != nil {
+		slog.Error("startup failed", "error", err)
+		os.Exit(1)
+	}
+	// FTS5 depends on the build, so the SQLite migrations leave the search index to us
+	if db.Dialector.Name() == dialect.SQLite {
+		if err := bug.EnsureSearchIndex(db); errors.Is(err, bug.ErrSearchIndexUnsupported) {
+			slog.Info("built without sqlite_fts5, search falls back to pattern matching")
+		} else if err != nil {
+			slog.Warn("full-text search index not created", "error", err)
+		}
+	}
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		slog.Error("startup failed", "error", err)
+		os.Exit(1)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		slog.Error("startup failed", "error", err)
+		os.Exit(1)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter)
+	if err != nil {
+		slog.Error("startup failed", "error", err)
+		os.Exit(1)
+	}
 
 	gin.SetMode(gin.ReleaseMode)
-	r := gin.Default()
+	r := gin.New()
+	r.Use(tracing.Middleware(), middleware.RequestID(), middleware.AccessLog(), middleware.Recovery(), metrics.Middleware())
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"https://shit-happens.vercel.app", "http://localhost:5173"},
+		AllowOrigins:     cfg.CORS.AllowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PATCH", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.ManageTokenHeader, middleware.RequestIDHeader, "traceparent", "tracestate"},
+		ExposeHeaders:    []string{"Content-Length", "Link", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
-	bug.RegisterRoutes(r, db)
-	upvote.RegisterRoutes(r, db)
-	tag.RegisterRoutes(r, db)
 
-	r.Run()
+	// the background jobs outlive the drain, and the dispatcher finishes its
+	// deliveries before returning, so events of the last requests still reach
+	// the webhooks
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	var jobs sync.WaitGroup
+	run := func(job func(context.Context)) {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			job(jobsCtx)
+		}()
+	}
+	// keep confessions.hot_score fresh for /confessions/hot
+	ranker := ranking.NewRanker(db, cfg.Ranking.Gravity, cfg.Ranking.Window)
+	run(func(ctx context.Context) { ranker.Run(ctx, cfg.Ranking.RefreshInterval) })
+	// forward confession and vote events to the registered webhooks
+	dispatcher := webhook.NewDispatcher(db, events.Default)
+	dispatcher.DrainTimeout = cfg.Server.ShutdownTimeout
+	run(dispatcher.Run)
+	// forget idle clients of the rate limiters
+	run(middleware.CleanupVisitors)
+	run(middleware.CleanupUpvoteVisitors)
+
+	bug.RegisterRoutes(r, db, cfg)
+	upvote.RegisterRoutes(r, db, cfg)
+	tag.RegisterRoutes(r, db, cfg)
+	moderation.RegisterRoutes(r, db, cfg)
+	comment.RegisterRoutes(r, db, cfg)
+	reaction.RegisterRoutes(r, db, cfg)
+	events.RegisterRoutes(r)
+	webhook.RegisterRoutes(r, db, cfg)
+	feed.RegisterRoutes(r, db, cfg)
+	openapi.RegisterRoutes(r, cfg)
+	health.RegisterRoutes(r, db, cfg)
+	// scrapers authenticate like admins when metrics.requireAdmin is set
+	if cfg.Metrics.RequireAdmin {
+		metrics.RegisterRoutes(r, middleware.AdminAuthMiddleware(cfg.Admin))
+	} else {
+		metrics.RegisterRoutes(r)
+	}
+	r.NoRoute(func(c *gin.Context) {
+		problem.NotFound(c, "no such route")
+	})
+
+	// validated by config.Load
+	addr, _ := config.ListenAddr(cfg.Server.Port)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	srv.RegisterOnShutdown(events.CloseStreams)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "addr", srv.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		slog.Error("server failed", "error", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+	stop()
+
+	timeout := cfg.Server.ShutdownTimeout
+	slog.Info("shutting down", "timeout", timeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("connections did not drain in time", "error", err)
+	}
+	stopJobs()
+	jobs.Wait()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("flushing traces failed", "error", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+	slog.Info("shutdown complete")
 }
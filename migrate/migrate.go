@@ -1,21 +1,118 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/Balaji01-4D/shit-happens/config"
-	confession "github.com/Balaji01-4D/shit-happens/internals/confession"
-	"github.com/Balaji01-4D/shit-happens/internals/tag"
-	"github.com/Balaji01-4D/shit-happens/internals/upvote"
+	"github.com/Balaji01-4D/shit-happens/internals/migrations"
 )
 
-func init() {
-	config.LoadEnvVariables()
+// where `create` writes new files when run from the repository root, one
+// directory per dialect below it
+const defaultDir = "internals/migrations"
+
+func usage() {
+	fmt.Fprint(flag.CommandLine.Output(), `usage: migrate <command>
+
+commands:
+  up             apply every pending migration
+  down [n]       roll back the latest n migrations (default 1)
+  status         list the migrations and whether they are applied
+  create <name>  add an empty up/down pair for every dialect to -dir
+
+flags:
+`)
+	flag.PrintDefaults()
 }
 
 func main() {
-	cfg := config.Load()
-	db := config.InitDB(cfg)
+	dir := flag.String("dir", defaultDir, "directory the create command writes to")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// create only touches the source tree, no database needed
+	if cmd := flag.Arg(0); cmd == "create" {
+		if flag.NArg() != 2 {
+			log.Fatal("usage: migrate create <name>")
+		}
+		created, err := migrations.Create(*dir, flag.Arg(1))
+		for _, path := range created {
+			fmt.Printf("created %s\n", path)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := config.InitDB(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	m, err := migrations.New(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx := context.Background()
 
-	db.AutoMigrate(&confession.Confession{})
-	db.AutoMigrate(&upvote.Upvote{})
-	db.AutoMigrate(&tag.Tag{})
+	switch flag.Arg(0) {
+	case "up":
+		done, err := m.Up(ctx)
+		for _, mig := range done {
+			fmt.Printf("applied %s\n", mig.ID())
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(done) == 0 {
+			fmt.Println("already up to date")
+		}
+	case "down":
+		n := 1
+		if flag.NArg() > 1 {
+			if n, err = strconv.Atoi(flag.Arg(1)); err != nil || n < 1 {
+				log.Fatalf("down takes a positive number of migrations, got %q", flag.Arg(1))
+			}
+		}
+		for i := 0; i < n; i++ {
+			mig, err := m.Down(ctx)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if mig == nil {
+				fmt.Println("nothing to roll back")
+				break
+			}
+			fmt.Printf("rolled back %s\n", mig.ID())
+		}
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Local().Format(time.DateTime)
+			}
+			fmt.Printf("%-40s %s\n", s.ID(), state)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
 }
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Balaji01-4D/shit-happens/internals/comment"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupRouterComment(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	r, db := setupRouter(t)
+	if err := db.AutoMigrate(&comment.Comment{}, &comment.CommentUpvote{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM comment_upvotes")
+		db.Exec("DELETE FROM comments")
+	})
+	comment.RegisterRoutes(r, db, testConfig())
+	return r, db
+}
+
+func postComment(t *testing.T, r *gin.Engine, confessionID uint, body string, parentID *uint) uint {
+	t.Helper()
+	payload := map[string]any{"body": body}
+	if parentID != nil {
+		payload["parentId"] = *parentID
+	}
+	w := doJSONRequest(r, http.MethodPost, "/confessions/"+jsonNumber(confessionID)+"/comments", payload)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create comment: status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		ID uint `json:"id"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.ID
+}
+
+type commentNode struct {
+	ID       uint          `json:"id"`
+	ParentID *uint         `json:"parentId"`
+	Body     string        `json:"body"`
+	Upvotes  int           `json:"upvotes"`
+	Replies  []commentNode `json:"replies"`
+}
+
+func commentCount(t *testing.T, r *gin.Engine, confessionID uint) int {
+	t.Helper()
+	w := doJSONRequest(r, http.MethodGet, "/confessions/"+jsonNumber(confessionID), nil)
+	var resp struct {
+		CommentCount int `json:"commentCount"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.CommentCount
+}
+
+func TestComments_Validation(t *testing.T) {
+	r, _ := setupRouterComment(t)
+	id := createConfession(t, r, "Needs help", "my goroutines leak", "go", nil)
+	other := createConfession(t, r, "Other post", "an unrelated confession", "go", nil)
+	path := "/confessions/" + jsonNumber(id) + "/comments"
+
+	if w := doJSONRequest(r, http.MethodPost, path, map[string]any{"body": ""}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for empty body, got %d", w.Code)
+	}
+	if w := doJSONRequest(r, http.MethodPost, path, map[string]any{"body": " \n\t "}); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "notblank") {
+		t.Fatalf("expected 400 for a blank body, got %d (%s)", w.Code, w.Body.String())
+	}
+	if w := doJSONRequest(r, http.MethodPost, "/confessions/999999/comments", map[string]any{"body": "hi"}); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown confession, got %d", w.Code)
+	}
+	foreign := postComment(t, r, other, "comment elsewhere", nil)
+	if w := doJSONRequest(r, http.MethodPost, path, map[string]any{"body": "reply", "parentId": foreign}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for parent on another confession, got %d", w.Code)
+	}
+}
+
+func TestComments_ThreadAndAdminDelete(t *testing.T) {
+	r, _ := setupRouterComment(t)
+	id := createConfession(t, r, "Deadlock", "two mutexes, wrong order", "go", nil)
+
+	root := postComment(t, r, id, "Lock them in a **fixed order**.", nil)
+	reply := postComment(t, r, id, "This fixed it, thanks", &root)
+	postComment(t, r, id, "Same here", &reply)
+	second := postComment(t, r, id, "Try `go vet`", nil)
+
+	if n := commentCount(t, r, id); n != 4 {
+		t.Fatalf("expected commentCount 4, got %d", n)
+	}
+
+	w := doJSONRequest(r, http.MethodGet, "/confessions/"+jsonNumber(id)+"/comments", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var thread []commentNode
+	_ = json.Unmarshal(w.Body.Bytes(), &thread)
+	if len(thread) != 2 || thread[0].ID != root || thread[1].ID != second {
+		t.Fatalf("unexpected top-level comments: %s", w.Body.String())
+	}
+	if thread[0].Body != "Lock them in a **fixed order**." {
+		t.Fatalf("markdown body should be stored verbatim, got %q", thread[0].Body)
+	}
+	if len(thread[0].Replies) != 1 || thread[0].Replies[0].ID != reply || len(thread[0].Replies[0].Replies) != 1 {
+		t.Fatalf("unexpected reply tree: %s", w.Body.String())
+	}
+
+	w = doJSONRequest(r, http.MethodGet, "/confessions/"+jsonNumber(id)+"/comments?offset=1&limit=1&envelope=1", nil)
+	var page struct {
+		Items []commentNode `json:"items"`
+		Total int64         `json:"total"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &page)
+	if page.Total != 2 || len(page.Items) != 1 || page.Items[0].ID != second || len(page.Items[0].Replies) != 0 {
+		t.Fatalf("expected the second top-level comment alone on page 2: %s", w.Body.String())
+	}
+
+	if w := doJSONRequest(r, http.MethodDelete, "/comments/"+jsonNumber(root), nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for anonymous delete, got %d", w.Code)
+	}
+	if w := doAdminRequest(r, http.MethodDelete, "/comments/"+jsonNumber(root), nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if n := commentCount(t, r, id); n != 1 {
+		t.Fatalf("deleting a comment should remove its replies, commentCount=%d", n)
+	}
+	if w := doAdminRequest(r, http.MethodDelete, "/comments/"+jsonNumber(reply), nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for already removed reply, got %d", w.Code)
+	}
+}
+
+func TestComments_UpvoteDedupe(t *testing.T) {
+	r, _ := setupRouterComment(t)
+	id := createConfession(t, r, "Flaky test", "passes only on fridays", "go", nil)
+	cid := postComment(t, r, id, "Check your time zone handling", nil)
+
+	upvoteFrom := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/comments/"+jsonNumber(cid)+"/upvote", strings.NewReader(""))
+		req.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := upvoteFrom("10.0.0.1:1234"); !strings.Contains(w.Body.String(), "upvote recorded") {
+		t.Fatalf("expected first upvote recorded, got %d %s", w.Code, w.Body.String())
+	}
+	if w := upvoteFrom("10.0.0.1:1234"); !strings.Contains(w.Body.String(), "already upvoted") {
+		t.Fatalf("expected duplicate upvote rejected, got %d %s", w.Code, w.Body.String())
+	}
+	upvoteFrom("10.0.0.2:1234")
+
+	if w := upvoteFrom("10.0.0.3:1234"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/comments/999999/upvote", nil)
+	req.RemoteAddr = "10.0.0.4:1234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown comment, got %d", w.Code)
+	}
+
+	w = doJSONRequest(r, http.MethodGet, "/confessions/"+jsonNumber(id)+"/comments", nil)
+	var thread []commentNode
+	_ = json.Unmarshal(w.Body.Bytes(), &thread)
+	if len(thread) != 1 || thread[0].Upvotes != 3 {
+		t.Fatalf("expected 3 upvotes, got %s", w.Body.String())
+	}
+}
+
+func TestComments_AcceptedFix(t *testing.T) {
+	r, _ := setupRouterComment(t)
+	w := doJSONRequest(r, http.MethodPost, "/confessions", map[string]any{
+		"title": "Nil map panic", "description": "assignment to entry in nil map", "language": "go",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create confession: %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		ID          uint   `json:"id"`
+		ManageToken string `json:"manageToken"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	id := created.ID
+	other := createConfession(t, r, "Other bug", "a different confession entirely", "go", nil)
+	fix := postComment(t, r, id, "Initialise it with `make(map[string]int)`", nil)
+	foreign := postComment(t, r, other, "not related", nil)
+
+	accept := func(token string, commentID uint) *httptest.ResponseRecorder {
+		body := strings.NewReader(`{"commentId":` + jsonNumber(commentID) + `}`)
+		req := httptest.NewRequest(http.MethodPut, "/confessions/"+jsonNumber(id)+"/accepted-comment", body)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("X-Manage-Token", token)
+		}
+		req.RemoteAddr = nextRemoteAddr()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := accept("", fix); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", w.Code)
+	}
+	if w := accept("wrong", fix); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with wrong token, got %d", w.Code)
+	}
+	if w := accept(created.ManageToken, foreign); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for comment on another confession, got %d", w.Code)
+	}
+	if w := accept(created.ManageToken, 999999); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown comment, got %d", w.Code)
+	}
+	if w := accept(created.ManageToken, fix); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%s)", w.Code, w.Body.String())
+	}
+
+	w = doJSONRequest(r, http.MethodGet, "/confessions/"+jsonNumber(id), nil)
+	var got struct {
+		Solved            bool  `json:"solved"`
+		AcceptedCommentID *uint `json:"acceptedCommentId"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &got)
+	if !got.Solved || got.AcceptedCommentID == nil || *got.AcceptedCommentID != fix {
+		t.Fatalf("expected confession solved by comment %d, got %s", fix, w.Body.String())
+	}
+
+	if w := doJSONRequest(r, http.MethodGet, "/confessions/search?solved=true", nil); !listContainsID(w.Body.Bytes(), id) || listContainsID(w.Body.Bytes(), other) {
+		t.Fatalf("solved=true should return only the solved confession: %s", w.Body.String())
+	}
+	if w := doJSONRequest(r, http.MethodGet, "/confessions/search?solved=false", nil); listContainsID(w.Body.Bytes(), id) || !listContainsID(w.Body.Bytes(), other) {
+		t.Fatalf("solved=false should exclude the solved confession: %s", w.Body.String())
+	}
+	if w := doJSONRequest(r, http.MethodGet, "/confessions/search?solved=maybe", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid solved, got %d", w.Code)
+	}
+	if w := doJSONRequest(r, http.MethodGet, "/confessions/unsolved", nil); listContainsID(w.Body.Bytes(), id) || !listContainsID(w.Body.Bytes(), other) {
+		t.Fatalf("unsolved feed should list only open confessions: %s", w.Body.String())
+	}
+
+	// removing the accepted comment reopens the confession
+	if w := doAdminRequest(r, http.MethodDelete, "/comments/"+jsonNumber(fix), nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w := doJSONRequest(r, http.MethodGet, "/confessions/unsolved", nil); !listContainsID(w.Body.Bytes(), id) {
+		t.Fatalf("confession should be unsolved after its fix was deleted: %s", w.Body.String())
+	}
+}
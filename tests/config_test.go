@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+)
+
+// configFile writes a YAML config file and points CONFIG_FILE at it
+func configFile(t *testing.T, yaml string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+}
+
+// clearConfigEnv unsets the variables a developer shell may carry, so only
+// what the test sets applies
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"DATABASE_URL", "PORT", "ADMIN_USERNAME", "ADMIN_PASSWORD", "LOG_LEVEL",
+		"REACTION_KINDS", "HOT_GRAVITY", "OTEL_TRACES_EXPORTER", "RATE_LIMIT_POSTS_BURST"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestConfig_FileThenEnv(t *testing.T) {
+	clearConfigEnv(t)
+	configFile(t, `
+database:
+  url: postgres://file
+server:
+  port: "9090"
+admin:
+  username: root
+  password: from-file
+rateLimit:
+  posts: {requests: 5, per: 1m, burst: 2}
+pagination:
+  tags: {defaultLimit: 25, maxLimit: 200}
+feeds:
+  publicBaseURL: https://bugs.example.com/
+`)
+	t.Setenv("PORT", "9191")
+	t.Setenv("ADMIN_PASSWORD", "from-env")
+	t.Setenv("RATE_LIMIT_POSTS_BURST", "7")
+	t.Setenv("REACTION_KINDS", " Facepalm, rip ,rip")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("expected a valid configuration, got %v", err)
+	}
+	if cfg.Database.URL != "postgres://file" || cfg.Admin.Username != "root" || cfg.Pagination.Tags.MaxLimit != 200 {
+		t.Fatalf("file settings not applied: %+v", cfg)
+	}
+	if cfg.Server.Port != "9191" || cfg.Admin.Password != "from-env" {
+		t.Fatalf("environment should override the file, got port %q password %q", cfg.Server.Port, cfg.Admin.Password)
+	}
+	if want := (config.Limit{Requests: 5, Per: time.Minute, Burst: 7}); cfg.RateLimit.Posts != want {
+		t.Fatalf("expected posts limit %+v, got %+v", want, cfg.RateLimit.Posts)
+	}
+	if !slices.Equal(cfg.Reactions.Kinds, []string{"facepalm", "rip"}) {
+		t.Fatalf("expected normalized reaction kinds, got %v", cfg.Reactions.Kinds)
+	}
+	if cfg.Feeds.PublicBaseURL != "https://bugs.example.com" {
+		t.Fatalf("expected trailing slash trimmed, got %q", cfg.Feeds.PublicBaseURL)
+	}
+	// untouched settings keep their defaults
+	if cfg.Pagination.Confessions != config.Default().Pagination.Confessions || cfg.Server.ShutdownTimeout != 15*time.Second {
+		t.Fatalf("defaults lost: %+v %s", cfg.Pagination.Confessions, cfg.Server.ShutdownTimeout)
+	}
+}
+
+func TestConfig_ReportsEveryInvalidSetting(t *testing.T) {
+	clearConfigEnv(t)
+	configFile(t, `
+database:
+  url: postgres://file
+cors:
+  allowedOrigins: [example.com]
+pagination:
+  tags: {defaultLimit: 50, maxLimit: 10}
+log:
+  level: loud
+tracing:
+  exporter: jaeger
+`)
+
+	_, err := config.Load()
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+	for _, want := range []string{
+		"admin.username: is required",
+		"admin.password: is required",
+		"cors.allowedOrigins[0]:",
+		"pagination.tags.maxLimit:",
+		"log.level:",
+		"tracing.exporter: must be none, otlp, stdout or console",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error lacks %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestConfig_RejectsMalformedInput(t *testing.T) {
+	clearConfigEnv(t)
+	configFile(t, "server:\n  prot: \"8080\"\n")
+	if _, err := config.Load(); err == nil || !strings.Contains(err.Error(), "prot") {
+		t.Fatalf("expected the misspelt key to be reported, got %v", err)
+	}
+
+	configFile(t, "")
+	t.Setenv("HOT_GRAVITY", "steep")
+	if _, err := config.Load(); err == nil || !strings.Contains(err.Error(), "HOT_GRAVITY") {
+		t.Fatalf("expected the bad variable to be named, got %v", err)
+	}
+
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected an explicit but missing config file to fail")
+	}
+}
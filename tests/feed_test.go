@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/feed"
+	"github.com/gin-gonic/gin"
+)
+
+func setupRouterFeed(t *testing.T, cfg *config.Config) *gin.Engine {
+	t.Helper()
+	r, db := setupRouter(t)
+	feed.RegisterRoutes(r, db, cfg)
+	return r
+}
+
+func getFeed(r *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestFeeds_AtomAndConditionalGet(t *testing.T) {
+	cfg := testConfig()
+	cfg.Feeds.CacheTTL = 0
+	r := setupRouterFeed(t, cfg)
+	createConfession(t, r, "Leaky goroutine", "a goroutine never returned", "go", []string{"concurrency"})
+	createConfession(t, r, "Java heap", "out of memory again & again", "java", nil)
+
+	w := getFeed(r, "/feeds/latest.atom", nil)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/atom+xml") {
+		t.Fatalf("expected atom feed, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var atom struct {
+		Updated string `xml:"updated"`
+		Entries []struct {
+			Title string `xml:"title"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &atom); err != nil {
+		t.Fatalf("invalid xml: %v", err)
+	}
+	if len(atom.Entries) != 2 || atom.Entries[0].Title != "Java heap" || atom.Updated == "" {
+		t.Fatalf("unexpected feed: %s", w.Body.String())
+	}
+
+	etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatalf("expected ETag and Last-Modified headers")
+	}
+	if w := getFeed(r, "/feeds/latest.atom", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for matching ETag, got %d", w.Code)
+	}
+	if w := getFeed(r, "/feeds/latest.atom", map[string]string{"If-Modified-Since": lastModified}); w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for If-Modified-Since, got %d", w.Code)
+	}
+
+	w = getFeed(r, "/feeds/tags/concurrency.atom", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Leaky goroutine") || strings.Contains(w.Body.String(), "Java heap") {
+		t.Fatalf("unexpected tag feed: %d %s", w.Code, w.Body.String())
+	}
+	if w := getFeed(r, "/feeds/tags/concurrency.json", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown feed format, got %d", w.Code)
+	}
+}
+
+func TestFeeds_TrendingRSS(t *testing.T) {
+	r := setupRouterFeed(t, testConfig())
+	createConfession(t, r, "Timezones", "daylight saving broke cron <again>", "python", nil)
+
+	w := getFeed(r, "/feeds/trending.rss", nil)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/rss+xml") {
+		t.Fatalf("expected rss feed, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var rss struct {
+		Channel struct {
+			Items []struct {
+				Title       string `xml:"title"`
+				Description string `xml:"description"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &rss); err != nil {
+		t.Fatalf("invalid xml: %v", err)
+	}
+	if len(rss.Channel.Items) != 1 || rss.Channel.Items[0].Description != "daylight saving broke cron <again>" {
+		t.Fatalf("unexpected rss: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Balaji01-4D/shit-happens/config"
+	"github.com/Balaji01-4D/shit-happens/internals/health"
+	"github.com/Balaji01-4D/shit-happens/internals/migrations"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func readiness(t *testing.T, db *gorm.DB, cfg *config.Config) (int, health.Status) {
+	t.Helper()
+	r := gin.New()
+	health.RegisterRoutes(r, db, cfg)
+	w := doJSONRequest(r, http.MethodGet, "/readyz", nil)
+	var status health.Status
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("invalid readiness body: %s", w.Body.String())
+	}
+	return w.Code, status
+}
+
+func TestHealth_LiveAndReady(t *testing.T) {
+	db := migrationsDB(t)
+	m, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("no sqlite migrations: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	r := gin.New()
+	health.RegisterRoutes(r, db, testConfig())
+	if w := doJSONRequest(r, http.MethodGet, "/healthz", nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 from /healthz, got %d", w.Code)
+	}
+
+	code, status := readiness(t, db, testConfig())
+	if code != http.StatusOK || status.Checks["database"] != "ok" || status.Checks["migrations"] != "ok" {
+		t.Fatalf("expected ready, got %d %+v", code, status)
+	}
+}
+
+func TestHealth_NotReady(t *testing.T) {
+	// a database of its own: nothing migrated yet
+	db, err := gorm.Open(sqlite.Open("file:readyz_unmigrated?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite test db: %v", err)
+	}
+
+	code, status := readiness(t, db, testConfig())
+	if code != http.StatusServiceUnavailable || status.Checks["database"] != "ok" || status.Checks["migrations"] != "failing" {
+		t.Fatalf("expected unmigrated database to be unready, got %d %+v", code, status)
+	}
+
+	cfg := testConfig()
+	cfg.Database.AllowPendingMigrations = true
+	code, status = readiness(t, db, cfg)
+	if code != http.StatusOK || status.Status != "degraded" || status.Checks["migrations"] != "degraded" {
+		t.Fatalf("expected allowed pending migrations to only degrade readiness, got %d %+v", code, status)
+	}
+
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+	code, status = readiness(t, db, testConfig())
+	if code != http.StatusServiceUnavailable || status.Checks["database"] != "failing" {
+		t.Fatalf("expected closed database to be unready, got %d %+v", code, status)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	confpkg "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/logging"
+	"github.com/Balaji01-4D/shit-happens/internals/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// captureLogs sends the default logger to a buffer for the rest of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(logging.New(&buf, "info"))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	sc := bufio.NewScanner(buf)
+	for sc.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("log line is not JSON: %q", sc.Text())
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func setupRouterLogging(t *testing.T, db *gorm.DB) *gin.Engine {
+	t.Helper()
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.AccessLog(), middleware.Recovery())
+	confpkg.RegisterRoutes(r, db, testConfig())
+	return r
+}
+
+func TestLogging_RequestIDAndAccessLog(t *testing.T) {
+	_, db := setupRouter(t)
+	logs := captureLogs(t)
+	r := setupRouterLogging(t, db)
+
+	req := httptest.NewRequest(http.MethodGet, "/confessions/42", nil)
+	req.Header.Set(middleware.RequestIDHeader, "trace-abc-123")
+	req.RemoteAddr = "203.0.113.9:4000"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got := w.Header().Get(middleware.RequestIDHeader); got != "trace-abc-123" {
+		t.Fatalf("expected the caller's request id echoed, got %q", got)
+	}
+
+	w = doJSONRequest(r, http.MethodGet, "/confessions", nil)
+	generated := w.Header().Get(middleware.RequestIDHeader)
+	if len(generated) != 32 {
+		t.Fatalf("expected a generated request id, got %q", generated)
+	}
+
+	lines := logLines(t, logs)
+	if len(lines) != 2 {
+		t.Fatalf("expected one access line per request, got %d", len(lines))
+	}
+	first := lines[0]
+	if first["request_id"] != "trace-abc-123" || first["route"] != "/confessions/:id" || first["status"] != float64(http.StatusNotFound) {
+		t.Fatalf("unexpected access line: %v", first)
+	}
+	if first["level"] != "WARN" || first["latency_ms"] == nil {
+		t.Fatalf("expected a WARN line with latency, got %v", first)
+	}
+	if ip, _ := first["client_ip_hash"].(string); ip == "" || strings.Contains(logs.String(), "203.0.113.9") {
+		t.Fatalf("client IP must be logged hashed only: %v", first)
+	}
+	if lines[1]["request_id"] != generated {
+		t.Fatalf("expected the generated id in the log, got %v", lines[1]["request_id"])
+	}
+}
+
+func TestLogging_HandlerErrorLogged(t *testing.T) {
+	// a closed database makes every query fail
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	_ = sqlDB.Close()
+
+	logs := captureLogs(t)
+	r := setupRouterLogging(t, db)
+
+	w := doJSONRequest(r, http.MethodGet, "/confessions/1", nil)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "closed") {
+		t.Fatalf("the cause must not reach the client: %s", w.Body.String())
+	}
+	id := w.Header().Get(middleware.RequestIDHeader)
+
+	lines := logLines(t, logs)
+	if len(lines) != 2 {
+		t.Fatalf("expected an error line and an access line, got %d", len(lines))
+	}
+	failure := lines[0]
+	if failure["level"] != "ERROR" || failure["msg"] != "failed to fetch confession" || failure["request_id"] != id {
+		t.Fatalf("unexpected error line: %v", failure)
+	}
+	if cause, _ := failure["error"].(string); !strings.Contains(cause, "closed") {
+		t.Fatalf("expected the database error in the log, got %v", failure["error"])
+	}
+	if lines[1]["level"] != "ERROR" || lines[1]["status"] != float64(http.StatusInternalServerError) {
+		t.Fatalf("unexpected access line: %v", lines[1])
+	}
+}
+
+func TestLogging_HashIPIsKeyed(t *testing.T) {
+	ip := "203.0.113.9"
+	plain := sha256.Sum256([]byte(ip))
+	got := logging.HashIP(ip)
+	if got == hex.EncodeToString(plain[:8]) {
+		t.Fatal("client IP hash is a plain SHA-256, reversible by brute force")
+	}
+	if len(got) != 16 || got != logging.HashIP(ip) || got == logging.HashIP("203.0.113.10") {
+		t.Fatalf("expected a stable 8 byte hash per address, got %q", got)
+	}
+}
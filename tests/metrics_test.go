@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	confpkg "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/metrics"
+	"github.com/Balaji01-4D/shit-happens/internals/middleware"
+	"github.com/Balaji01-4D/shit-happens/internals/upvote"
+	"github.com/gin-gonic/gin"
+)
+
+func setupRouterMetrics(t *testing.T, guards ...gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	_, db := setupRouter(t)
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		t.Fatalf("failed to install metrics plugin: %v", err)
+	}
+	r := gin.New()
+	r.Use(metrics.Middleware())
+	confpkg.RegisterRoutes(r, db, testConfig())
+	upvote.RegisterRoutes(r, db, testConfig())
+	metrics.RegisterRoutes(r, guards...)
+	return r
+}
+
+func TestMetrics_Exposition(t *testing.T) {
+	r := setupRouterMetrics(t)
+	id := createConfession(t, r, "Metrics matter", "nobody watched the dashboards", "go", nil)
+
+	// the upvote limiter allows a burst of 3 per client
+	path := fmt.Sprintf("/confessions/%d/upvote", id)
+	for i := 0; i < 4; i++ {
+		voteAs(r, http.MethodPost, path, "198.51.100.20:1234")
+	}
+
+	w := doJSONRequest(r, http.MethodGet, "/metrics", nil)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("expected prometheus text format, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`mydearbug_http_requests_total{method="POST",route="/confessions",status="201"}`,
+		`mydearbug_http_request_duration_seconds_bucket{method="POST",route="/confessions/:id/upvote",status="200",le=`,
+		`mydearbug_http_requests_total{method="POST",route="/confessions/:id/upvote",status="429"}`,
+		`mydearbug_rate_limit_rejections_total{limiter="upvote"}`,
+		`mydearbug_rate_limit_visitors{limiter="upvote"}`,
+		`mydearbug_rate_limit_visitors{limiter="post"}`,
+		`mydearbug_confessions_created_total`,
+		`mydearbug_upvotes_recorded_total`,
+		`mydearbug_db_query_duration_seconds_count{operation="create",table="confessions"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output lacks %s", want)
+		}
+	}
+}
+
+func TestMetrics_AdminGuard(t *testing.T) {
+	r := setupRouterMetrics(t, middleware.AdminAuthMiddleware(testConfig().Admin))
+	if w := doJSONRequest(r, http.MethodGet, "/metrics", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without credentials, got %d", w.Code)
+	}
+	if w := doAdminRequest(r, http.MethodGet, "/metrics", nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for the admin, got %d", w.Code)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Balaji01-4D/shit-happens/internals/migrations"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func migrationsDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite test db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func parseMigrations(t *testing.T, files fstest.MapFS) []migrations.Migration {
+	t.Helper()
+	ms, err := migrations.Parse(files)
+	if err != nil {
+		t.Fatalf("failed to parse migrations: %v", err)
+	}
+	return ms
+}
+
+func TestMigrations_UpStatusDown(t *testing.T) {
+	db := migrationsDB(t)
+	ctx := context.Background()
+	ms := parseMigrations(t, fstest.MapFS{
+		"0001_notes.up.sql":       {Data: []byte("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);")},
+		"0001_notes.down.sql":     {Data: []byte("DROP TABLE notes;")},
+		"0002_note_tags.up.sql":   {Data: []byte("ALTER TABLE notes ADD COLUMN tag TEXT;\nCREATE INDEX idx_notes_tag ON notes (tag);")},
+		"0002_note_tags.down.sql": {Data: []byte("DROP INDEX idx_notes_tag;\nALTER TABLE notes DROP COLUMN tag;")},
+		"README.md":               {Data: []byte("ignored")},
+	})
+	if len(ms) != 2 || ms[0].ID() != "0001_notes" || ms[1].ID() != "0002_note_tags" {
+		t.Fatalf("expected two ordered migrations, got %+v", ms)
+	}
+	m := migrations.NewMigrator(db, ms)
+
+	if err := m.Check(ctx); !errors.Is(err, migrations.ErrPending) {
+		t.Fatalf("expected a fresh database to have pending migrations, got %v", err)
+	}
+	if db.Migrator().HasTable("schema_migrations") {
+		t.Fatal("checking must not write to the database")
+	}
+
+	done, err := m.Up(ctx)
+	if err != nil || len(done) != 2 {
+		t.Fatalf("expected both migrations applied, got %d: %v", len(done), err)
+	}
+	if !db.Migrator().HasColumn("notes", "tag") {
+		t.Fatal("expected the second migration to add notes.tag")
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Fatalf("expected no pending migrations, got %v", err)
+	}
+	if done, err := m.Up(ctx); err != nil || len(done) != 0 {
+		t.Fatalf("expected a second up to do nothing, got %d: %v", len(done), err)
+	}
+
+	rolled, err := m.Down(ctx)
+	if err != nil || rolled == nil || rolled.Version != 2 {
+		t.Fatalf("expected the latest migration rolled back, got %+v: %v", rolled, err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if statuses[0].AppliedAt == nil || statuses[1].AppliedAt != nil {
+		t.Fatalf("expected only the first migration applied, got %+v", statuses)
+	}
+	if db.Migrator().HasColumn("notes", "tag") {
+		t.Fatal("expected notes.tag to be dropped")
+	}
+}
+
+func TestMigrations_FailureRollsBack(t *testing.T) {
+	db := migrationsDB(t)
+	ctx := context.Background()
+	m := migrations.NewMigrator(db, parseMigrations(t, fstest.MapFS{
+		"0001_ok.up.sql":     {Data: []byte("CREATE TABLE ok (id INTEGER PRIMARY KEY);")},
+		"0001_ok.down.sql":   {Data: []byte("DROP TABLE ok;")},
+		"0002_bad.up.sql":    {Data: []byte("CREATE TABLE half (id INTEGER);\nINSERT INTO missing VALUES (1);")},
+		"0002_bad.down.sql":  {Data: []byte("DROP TABLE half;")},
+		"0003_late.up.sql":   {Data: []byte("CREATE TABLE late (id INTEGER);")},
+		"0003_late.down.sql": {Data: []byte("DROP TABLE late;")},
+	}))
+
+	done, err := m.Up(ctx)
+	if err == nil || len(done) != 1 {
+		t.Fatalf("expected to stop after the first migration, got %d: %v", len(done), err)
+	}
+	if db.Migrator().HasTable("half") || db.Migrator().HasTable("late") {
+		t.Fatal("expected the failed migration rolled back and the later one skipped")
+	}
+	pending, err := m.Pending(ctx)
+	if err != nil || len(pending) != 2 || pending[0].Version != 2 {
+		t.Fatalf("expected 0002 and 0003 still pending, got %+v: %v", pending, err)
+	}
+}
+
+func TestMigrations_ParseRejectsHalfPairs(t *testing.T) {
+	_, err := migrations.Parse(fstest.MapFS{"0001_lonely.up.sql": {Data: []byte("SELECT 1;")}})
+	if err == nil {
+		t.Fatal("expected a migration without a down file to be rejected")
+	}
+}
+
+func TestMigrations_EmbeddedAndCreate(t *testing.T) {
+	var ids [][]string
+	for _, d := range migrations.Dialects {
+		ms, err := migrations.Embedded(d)
+		if err != nil {
+			t.Fatalf("embedded %s migrations invalid: %v", d, err)
+		}
+		var dialectIDs []string
+		for i, m := range ms {
+			if m.Version != int64(i+1) {
+				t.Fatalf("expected contiguous %s versions, %s is at position %d", d, m.ID(), i+1)
+			}
+			dialectIDs = append(dialectIDs, m.ID())
+		}
+		ids = append(ids, dialectIDs)
+	}
+	if !slices.Equal(ids[0], ids[1]) {
+		t.Fatalf("expected every dialect to have the same migrations, got %v", ids)
+	}
+
+	root := t.TempDir()
+	for _, d := range migrations.Dialects {
+		if err := os.Mkdir(filepath.Join(root, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"0001_first.up.sql", "0001_first.down.sql"} {
+			if err := os.WriteFile(filepath.Join(root, d, name), []byte("SELECT 1;"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	created, err := migrations.Create(root, "Add comment edits")
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if len(created) != 2*len(migrations.Dialects) {
+		t.Fatalf("expected an up/down pair per dialect, got %v", created)
+	}
+	for _, path := range created {
+		if !strings.HasPrefix(filepath.Base(path), "0002_add_comment_edits.") {
+			t.Fatalf("unexpected file name %s", path)
+		}
+	}
+	for _, d := range migrations.Dialects {
+		if _, err := migrations.Parse(os.DirFS(filepath.Join(root, d))); err != nil {
+			t.Fatalf("created %s files do not parse: %v", d, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Balaji01-4D/shit-happens/internals/moderation"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupRouterModeration(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	r, db := setupRouter(t)
+	if err := db.AutoMigrate(&moderation.Report{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM reports")
+	})
+	moderation.RegisterRoutes(r, db, testConfig())
+	return r, db
+}
+
+func TestReport_InvalidReason(t *testing.T) {
+	r, _ := setupRouterModeration(t)
+	id := createConfession(t, r, "Reported post", "this post will be reported", "go", nil)
+	w := doJSONRequest(r, http.MethodPost, "/confessions/"+jsonNumber(id)+"/report", map[string]any{"reason": "boring"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown reason, got %d", w.Code)
+	}
+	w = doJSONRequest(r, http.MethodPost, "/confessions/999999/report", map[string]any{"reason": "spam"})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown confession, got %d", w.Code)
+	}
+}
+
+func TestReport_AutoFlagHidesUntilApproved(t *testing.T) {
+	r, _ := setupRouterModeration(t)
+	id := createConfession(t, r, "Spammy confession", "buy cheap watches from my site", "go", nil)
+	path := "/confessions/" + jsonNumber(id)
+
+	for i := 0; i < 3; i++ {
+		w := doJSONRequest(r, http.MethodPost, path+"/report", map[string]any{"reason": "spam"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("report %d: expected 201, got %d (%s)", i, w.Code, w.Body.String())
+		}
+	}
+
+	listed := func() bool {
+		w := doJSONRequest(r, http.MethodGet, "/confessions?limit=100", nil)
+		return listContainsID(w.Body.Bytes(), id)
+	}
+	if listed() {
+		t.Fatalf("flagged confession should be hidden from listing")
+	}
+	if w := doJSONRequest(r, http.MethodGet, "/confessions/top?limit=100", nil); listContainsID(w.Body.Bytes(), id) {
+		t.Fatalf("flagged confession should be hidden from top")
+	}
+
+	if w := doJSONRequest(r, http.MethodGet, "/admin/moderation/queue", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for anonymous queue access, got %d", w.Code)
+	}
+	w := doAdminRequest(r, http.MethodGet, "/admin/moderation/queue", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var queue []struct {
+		Confession struct {
+			ID uint `json:"id"`
+		} `json:"confession"`
+		Reports []struct {
+			Reason string `json:"reason"`
+		} `json:"reports"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &queue)
+	if len(queue) != 1 || queue[0].Confession.ID != id || len(queue[0].Reports) != 3 {
+		t.Fatalf("unexpected queue: %s", w.Body.String())
+	}
+
+	if w := doAdminRequest(r, http.MethodPost, "/admin/moderation/"+jsonNumber(id)+"/approve", nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 on approve, got %d", w.Code)
+	}
+	if !listed() {
+		t.Fatalf("approved confession should be listed again")
+	}
+	if w := doAdminRequest(r, http.MethodPost, "/admin/moderation/"+jsonNumber(id)+"/reject", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 when rejecting a reviewed confession, got %d", w.Code)
+	}
+}
+
+func TestCreateConfession_CannotSelfFlag(t *testing.T) {
+	r, _ := setupRouter(t)
+	w := doJSONRequest(r, http.MethodPost, "/confessions", map[string]any{
+		"title":       "Sneaky flag",
+		"description": "clients must not control moderation state",
+		"language":    "go",
+		"isFlagged":   true,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	var created map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if created["isFlagged"] != false {
+		t.Fatalf("isFlagged must be ignored on create, got %v", created["isFlagged"])
+	}
+}
+
+func listContainsID(body []byte, id uint) bool {
+	var list []map[string]any
+	_ = json.Unmarshal(body, &list)
+	for _, c := range list {
+		if uintFromAny(c["id"]) == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReport_HasItsOwnRateLimit(t *testing.T) {
+	r, _ := setupRouterModeration(t)
+	id := createConfession(t, r, "Reported post", "this post will be reported", "go", nil)
+
+	post := func(path string, body map[string]any) int {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "203.0.113.7:12345"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// spend the posting budget of this client
+	confession := map[string]any{"title": "Rate limited", "description": "posting until refused", "language": "go"}
+	limited := false
+	for i := 0; i < 10 && !limited; i++ {
+		limited = post("/confessions", confession) == http.StatusTooManyRequests
+	}
+	if !limited {
+		t.Fatal("expected posting to be rate limited")
+	}
+
+	if code := post("/confessions/"+jsonNumber(id)+"/report", map[string]any{"reason": "spam"}); code != http.StatusCreated {
+		t.Fatalf("expected the report to draw on its own budget, got %d", code)
+	}
+}
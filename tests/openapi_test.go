@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Balaji01-4D/shit-happens/internals/comment"
+	"github.com/Balaji01-4D/shit-happens/internals/events"
+	"github.com/Balaji01-4D/shit-happens/internals/feed"
+	"github.com/Balaji01-4D/shit-happens/internals/health"
+	"github.com/Balaji01-4D/shit-happens/internals/metrics"
+	"github.com/Balaji01-4D/shit-happens/internals/moderation"
+	"github.com/Balaji01-4D/shit-happens/internals/openapi"
+	"github.com/Balaji01-4D/shit-happens/internals/reaction"
+	"github.com/Balaji01-4D/shit-happens/internals/tag"
+	"github.com/Balaji01-4D/shit-happens/internals/upvote"
+	"github.com/Balaji01-4D/shit-happens/internals/webhook"
+	"github.com/gin-gonic/gin"
+)
+
+// setupRouterAll registers every package the way main.go does
+func setupRouterAll(t *testing.T) *gin.Engine {
+	t.Helper()
+	r, db := setupRouter(t)
+	cfg := testConfig()
+	upvote.RegisterRoutes(r, db, cfg)
+	tag.RegisterRoutes(r, db, cfg)
+	moderation.RegisterRoutes(r, db, cfg)
+	comment.RegisterRoutes(r, db, cfg)
+	reaction.RegisterRoutes(r, db, cfg)
+	events.RegisterRoutes(r)
+	webhook.RegisterRoutes(r, db, cfg)
+	feed.RegisterRoutes(r, db, cfg)
+	openapi.RegisterRoutes(r, cfg)
+	metrics.RegisterRoutes(r)
+	health.RegisterRoutes(r, db, cfg)
+	return r
+}
+
+// specParam turns /feeds/tags/{name}.atom into gin's /feeds/tags/:name
+var specParam = regexp.MustCompile(`\{(\w+)\}[^/]*`)
+
+func TestOpenAPI_CoversEveryRoute(t *testing.T) {
+	r := setupRouterAll(t)
+
+	w := doJSONRequest(r, http.MethodGet, "/openapi.json", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var spec openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Fatalf("expected an OpenAPI 3 document, got %q", spec.OpenAPI)
+	}
+
+	documented := map[string]bool{}
+	for path, item := range spec.Paths {
+		for method := range item {
+			documented[strings.ToUpper(method)+" "+specParam.ReplaceAllString(path, ":$1")] = true
+		}
+	}
+
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if !documented[key] {
+			t.Errorf("route %s is not in the OpenAPI document", key)
+		}
+	}
+	for key := range documented {
+		if !registered[key] {
+			t.Errorf("OpenAPI document describes %s, which is not registered", key)
+		}
+	}
+}
+
+func TestOpenAPI_SchemasFollowDTOs(t *testing.T) {
+	r := setupRouterAll(t)
+	var spec openapi.Document
+	if err := json.Unmarshal(doJSONRequest(r, http.MethodGet, "/openapi.json", nil).Body.Bytes(), &spec); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+
+	req := spec.Components.Schemas["ConfessionRequest"]
+	if req == nil {
+		t.Fatalf("ConfessionRequest schema missing")
+	}
+	if strings.Join(req.Required, ",") != "title,description,language" {
+		t.Fatalf("unexpected required fields: %v", req.Required)
+	}
+	if title := req.Properties["title"]; title.MinLength == nil || *title.MinLength != 5 || *title.MaxLength != 100 {
+		t.Fatalf("title length constraints not taken from the binding tag: %+v", title)
+	}
+
+	// the manage token hash is json:"-" and must not leak into the contract
+	if _, ok := spec.Components.Schemas["Confession"].Properties["ManageTokenHash"]; ok {
+		t.Fatalf("hidden field documented")
+	}
+	if _, ok := spec.Components.Schemas["CreateConfessionResponse"].Properties["manageToken"]; !ok {
+		t.Fatalf("embedded confession response should carry manageToken")
+	}
+
+	limit := spec.Paths["/confessions"]["get"].Parameters[1]
+	if limit.Name != "limit" || limit.Schema.Default != float64(10) {
+		t.Fatalf("expected limit to default to 10, got %+v", limit)
+	}
+
+	w := doJSONRequest(r, http.MethodGet, "/docs", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "openapi.json") {
+		t.Fatalf("expected docs page, got %d", w.Code)
+	}
+}
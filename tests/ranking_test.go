@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	confpkg "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/ranking"
+	"github.com/Balaji01-4D/shit-happens/internals/upvote"
+)
+
+func TestHot_FreshVotesBeatOldVotes(t *testing.T) {
+	r, db := setupRouter(t)
+	now := time.Now()
+
+	old := createConfession(t, r, "Old classic bug", "everyone upvoted this a while ago", "go", nil)
+	fresh := createConfession(t, r, "Fresh new bug", "just posted and getting attention", "go", nil)
+
+	db.Model(&confpkg.Confession{}).Where("id = ?", old).
+		Updates(map[string]any{"created_at": now.Add(-10 * 24 * time.Hour), "upvotes": 20})
+	db.Model(&confpkg.Confession{}).Where("id = ?", fresh).Update("upvotes", 2)
+	for i := 0; i < 20; i++ {
+		db.Create(&upvote.Upvote{ConfessionID: old, IPHash: fmt.Sprintf("old-%d", i), ClientHash: fmt.Sprintf("old-%d", i), CreatedAt: now.Add(-10 * 24 * time.Hour)})
+	}
+	for i := 0; i < 2; i++ {
+		db.Create(&upvote.Upvote{ConfessionID: fresh, IPHash: fmt.Sprintf("new-%d", i), ClientHash: fmt.Sprintf("new-%d", i), CreatedAt: now.Add(-time.Hour)})
+	}
+
+	if _, err := ranking.NewRanker(db, ranking.DefaultGravity, ranking.DefaultWindow).Recompute(now); err != nil {
+		t.Fatalf("recompute failed: %v", err)
+	}
+
+	w := doJSONRequest(r, http.MethodGet, "/confessions/hot?limit=10", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var hot []struct {
+		ID       uint    `json:"id"`
+		HotScore float64 `json:"hotScore"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &hot)
+	if len(hot) != 2 || hot[0].ID != fresh || hot[1].ID != old {
+		t.Fatalf("expected fresh confession first, got %s", w.Body.String())
+	}
+	if hot[0].HotScore <= hot[1].HotScore {
+		t.Fatalf("hot scores out of order: %v <= %v", hot[0].HotScore, hot[1].HotScore)
+	}
+
+	w = doJSONRequest(r, http.MethodGet, "/confessions/top?limit=1", nil)
+	var top []struct {
+		ID uint `json:"id"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &top)
+	if len(top) != 1 || top[0].ID != old {
+		t.Fatalf("top should still rank by raw upvotes, got %s", w.Body.String())
+	}
+}
+
+func TestHot_NewConfessionsAreRankedBeforeRecompute(t *testing.T) {
+	r, db := setupRouter(t)
+	now := time.Now()
+
+	stale := createConfession(t, r, "Stale bug", "posted long ago", "go", nil)
+	db.Model(&confpkg.Confession{}).Where("id = ?", stale).Update("created_at", now.Add(-2*ranking.DefaultWindow))
+	if _, err := ranking.NewRanker(db, ranking.DefaultGravity, ranking.DefaultWindow).Recompute(now); err != nil {
+		t.Fatalf("recompute failed: %v", err)
+	}
+
+	id := createConfession(t, r, "Brand new bug", "nobody has voted yet", "go", nil)
+
+	w := doJSONRequest(r, http.MethodGet, "/confessions/hot?limit=10", nil)
+	var hot []struct {
+		ID       uint    `json:"id"`
+		HotScore float64 `json:"hotScore"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &hot)
+	if len(hot) != 2 || hot[0].ID != id || hot[1].ID != stale {
+		t.Fatalf("expected the new confession first, got %s", w.Body.String())
+	}
+	if hot[0].HotScore <= 0 || hot[1].HotScore != 0 {
+		t.Fatalf("expected a seeded score and a reset one, got %v and %v", hot[0].HotScore, hot[1].HotScore)
+	}
+}
+
+// runs against PostgreSQL too when TEST_DATABASE_URL points at one, where the
+// batched update has to type its parameters
+func TestHot_RecomputeStoresTheScores(t *testing.T) {
+	r, db := setupRouter(t)
+	now := time.Now()
+
+	var ids []uint
+	for i := 0; i < 3; i++ {
+		id := createConfession(t, r, fmt.Sprintf("Bug %d", i), "scored by the ranking job", "go", nil)
+		db.Model(&confpkg.Confession{}).Where("id = ?", id).Update("created_at", now.Add(-time.Duration(i)*time.Hour))
+		for v := 0; v < i; v++ {
+			db.Create(&upvote.Upvote{ConfessionID: id, IPHash: fmt.Sprintf("%d-%d", i, v), ClientHash: fmt.Sprintf("%d-%d", i, v), CreatedAt: now.Add(-time.Duration(v) * time.Hour)})
+		}
+		ids = append(ids, id)
+	}
+
+	n, err := ranking.NewRanker(db, ranking.DefaultGravity, ranking.DefaultWindow).Recompute(now)
+	if err != nil {
+		t.Fatalf("recompute failed: %v", err)
+	}
+	if n != len(ids) {
+		t.Fatalf("expected %d scored confessions, got %d", len(ids), n)
+	}
+	for i, id := range ids {
+		votes := make([]time.Time, i)
+		for v := range votes {
+			votes[v] = now.Add(-time.Duration(v) * time.Hour)
+		}
+		want := ranking.Score(now, now.Add(-time.Duration(i)*time.Hour), votes, ranking.DefaultGravity)
+		var got confpkg.Confession
+		if err := db.First(&got, id).Error; err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got.HotScore-want) > 1e-9 {
+			t.Fatalf("confession %d: expected hot score %v, got %v", id, want, got.HotScore)
+		}
+	}
+}
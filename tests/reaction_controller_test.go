@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Balaji01-4D/shit-happens/internals/reaction"
+	"github.com/gin-gonic/gin"
+)
+
+func setupRouterReaction(t *testing.T) *gin.Engine {
+	t.Helper()
+	r, db := setupRouter(t)
+	if err := db.AutoMigrate(&reaction.Reaction{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM reactions")
+		db.Exec("DELETE FROM reaction_counts")
+	})
+	reaction.RegisterRoutes(r, db, testConfig())
+	return r
+}
+
+func reactionCounts(t *testing.T, r *gin.Engine, id uint) map[string]int {
+	t.Helper()
+	w := doJSONRequest(r, http.MethodGet, "/confessions/"+jsonNumber(id), nil)
+	var resp struct {
+		Reactions map[string]int `json:"reactions"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Reactions
+}
+
+func TestReactions_ReactAndRemove(t *testing.T) {
+	r := setupRouterReaction(t)
+	id := createConfession(t, r, "Prod on friday", "deployed at 5pm and went home", "go", nil)
+	path := "/confessions/" + jsonNumber(id) + "/reactions/"
+	const voter = "10.2.0.1:1234"
+
+	if w := voteAs(r, http.MethodPost, path+"yawn", "10.2.0.9:1234"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown kind, got %d", w.Code)
+	}
+	if w := voteAs(r, http.MethodPost, "/confessions/999999/reactions/rip", "10.2.0.9:1234"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown confession, got %d", w.Code)
+	}
+
+	voteAs(r, http.MethodPost, path+"facepalm", voter)
+	voteAs(r, http.MethodPost, path+"rip", voter)
+	voteAs(r, http.MethodPost, path+"facepalm", "10.2.0.2:1234")
+	if w := voteAs(r, http.MethodPost, path+"facepalm", voter); !strings.Contains(w.Body.String(), "already reacted") {
+		t.Fatalf("expected duplicate reaction rejected, got %s", w.Body.String())
+	}
+	if got := reactionCounts(t, r, id); got["facepalm"] != 2 || got["rip"] != 1 {
+		t.Fatalf("unexpected counts: %v", got)
+	}
+
+	// the voter used up the rate limiter burst; the second voter takes theirs back
+	if w := voteAs(r, http.MethodDelete, path+"facepalm", "10.2.0.2:1234"); !strings.Contains(w.Body.String(), "reaction removed") {
+		t.Fatalf("expected reaction removed, got %s", w.Body.String())
+	}
+	if w := voteAs(r, http.MethodDelete, path+"facepalm", "10.2.0.2:1234"); !strings.Contains(w.Body.String(), "not reacted") {
+		t.Fatalf("expected not reacted, got %s", w.Body.String())
+	}
+	if got := reactionCounts(t, r, id); got["facepalm"] != 1 || got["rip"] != 1 {
+		t.Fatalf("unexpected counts after removal: %v", got)
+	}
+}
+
+func TestReactions_SortListing(t *testing.T) {
+	r := setupRouterReaction(t)
+	none := createConfession(t, r, "Nothing special", "a perfectly ordinary bug", "go", nil)
+	one := createConfession(t, r, "Mildly funny", "a somewhat embarrassing bug", "go", nil)
+	two := createConfession(t, r, "Very funny", "a truly embarrassing bug", "go", nil)
+	voteAs(r, http.MethodPost, "/confessions/"+jsonNumber(one)+"/reactions/facepalm", "10.2.1.1:1234")
+	voteAs(r, http.MethodPost, "/confessions/"+jsonNumber(two)+"/reactions/facepalm", "10.2.1.1:1234")
+	voteAs(r, http.MethodPost, "/confessions/"+jsonNumber(two)+"/reactions/facepalm", "10.2.1.2:1234")
+
+	if w := doJSONRequest(r, http.MethodGet, "/confessions?reaction=yawn", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown reaction, got %d", w.Code)
+	}
+
+	w := doJSONRequest(r, http.MethodGet, "/confessions?reaction=facepalm", nil)
+	var list []struct {
+		ID uint `json:"id"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list) != 3 || list[0].ID != two || list[1].ID != one || list[2].ID != none {
+		t.Fatalf("expected order by facepalm count, got %s", w.Body.String())
+	}
+
+	// keyset pagination follows the reaction order
+	var ids []uint
+	next := ""
+	for i := 0; i < 3; i++ {
+		w := doJSONRequest(r, http.MethodGet, "/confessions?reaction=facepalm&limit=1&cursor="+next, nil)
+		var page struct {
+			Items []struct {
+				ID uint `json:"id"`
+			} `json:"items"`
+			NextCursor string `json:"nextCursor"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &page)
+		for _, it := range page.Items {
+			ids = append(ids, it.ID)
+		}
+		next = page.NextCursor
+	}
+	if len(ids) != 3 || ids[0] != two || ids[1] != one || ids[2] != none {
+		t.Fatalf("unexpected keyset order: %v", ids)
+	}
+	if w := doJSONRequest(r, http.MethodGet, "/confessions?cursor="+next, nil); next != "" && w.Code != http.StatusBadRequest {
+		t.Fatalf("expected reaction cursor rejected by recent listing, got %d", w.Code)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Balaji01-4D/shit-happens/internals/events"
+	"github.com/Balaji01-4D/shit-happens/internals/upvote"
+)
+
+type sseMessage struct {
+	Event string
+	Data  string
+}
+
+// readEvents parses the SSE stream into msgs until the body is closed
+func readEvents(resp *http.Response, msgs chan<- sseMessage) {
+	defer close(msgs)
+	scanner := bufio.NewScanner(resp.Body)
+	var msg sseMessage
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			msg.Event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			msg.Data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		case line == "" && msg.Event != "":
+			msgs <- msg
+			msg = sseMessage{}
+		}
+	}
+}
+
+func nextEvent(t *testing.T, msgs <-chan sseMessage) sseMessage {
+	t.Helper()
+	select {
+	case msg, ok := <-msgs:
+		if !ok {
+			t.Fatalf("stream closed")
+		}
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for an event")
+	}
+	return sseMessage{}
+}
+
+func TestStream_FilteredEvents(t *testing.T) {
+	r, db := setupRouter(t)
+	upvote.RegisterRoutes(r, db, testConfig())
+	events.RegisterRoutes(r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/stream?tag=postgres", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	msgs := make(chan sseMessage, 8)
+	go readEvents(resp, msgs)
+
+	createConfession(t, r, "Unrelated", "no postgres tag on this one", "go", []string{"redis"})
+	w := doJSONRequest(r, http.MethodPost, "/confessions", map[string]any{
+		"title": "Vacuum froze", "description": "autovacuum never finished", "language": "sql", "tags": []string{"postgres"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create confession: %d", w.Code)
+	}
+	var created struct {
+		ID          uint   `json:"id"`
+		ManageToken string `json:"manageToken"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+
+	msg := nextEvent(t, msgs)
+	if msg.Event != events.ConfessionCreated || !strings.Contains(msg.Data, "Vacuum froze") {
+		t.Fatalf("expected created event for the tagged confession, got %+v", msg)
+	}
+	if strings.Contains(msg.Data, created.ManageToken) || strings.Contains(msg.Data, "manageToken") {
+		t.Fatalf("stream must not leak the manage token: %s", msg.Data)
+	}
+
+	voteAs(r, http.MethodPost, "/confessions/"+jsonNumber(created.ID)+"/upvote", "10.3.0.1:1234")
+	msg = nextEvent(t, msgs)
+	if msg.Event != events.UpvoteRecorded || !strings.Contains(msg.Data, `"upvotes":1`) {
+		t.Fatalf("expected upvote event with the new count, got %+v", msg)
+	}
+
+	if w := doAdminRequest(r, http.MethodDelete, "/confessions/"+jsonNumber(created.ID), nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	msg = nextEvent(t, msgs)
+	if msg.Event != events.ConfessionDeleted || !strings.Contains(msg.Data, jsonNumber(created.ID)) {
+		t.Fatalf("expected deleted event, got %+v", msg)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	confpkg "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/middleware"
+	"github.com/Balaji01-4D/shit-happens/internals/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans routes every span to an in-memory recorder for the rest of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return rec
+}
+
+func TestTracing_SpansFollowTheRequest(t *testing.T) {
+	rec := recordSpans(t)
+	_, db := setupRouter(t)
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		t.Fatalf("failed to install tracing plugin: %v", err)
+	}
+	logs := captureLogs(t)
+	r := gin.New()
+	r.Use(tracing.Middleware(), middleware.RequestID(), middleware.AccessLog())
+	confpkg.RegisterRoutes(r, db, testConfig())
+
+	id := createConfession(t, r, "Traced at last", "the preload was the slow part", "go", []string{"perf"})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/confessions/%d", id), nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range rec.Ended() {
+		if s.SpanContext().TraceID().String() == traceID {
+			byName[s.Name()] = s
+		}
+	}
+	server, ok := byName["GET /confessions/:id"]
+	if !ok {
+		t.Fatalf("no server span in the caller's trace, got %v", spanNames(byName))
+	}
+	if server.Parent().SpanID().String() != "00f067aa0ba902b7" || !server.Parent().IsRemote() {
+		t.Fatalf("server span should continue the incoming traceparent, parent %v", server.Parent())
+	}
+
+	// request -> service -> statement -> preload
+	chain := []string{"GET /confessions/:id", "confession.Service.Get", "gorm.query confessions", "gorm.preload tags"}
+	for i := 1; i < len(chain); i++ {
+		child, ok := byName[chain[i]]
+		if !ok {
+			t.Fatalf("missing span %q, got %v", chain[i], spanNames(byName))
+		}
+		if child.Parent().SpanID() != byName[chain[i-1]].SpanContext().SpanID() {
+			t.Errorf("%q should be a child of %q", chain[i], chain[i-1])
+		}
+	}
+
+	var logged bool
+	for _, line := range logLines(t, logs) {
+		if line["msg"] == "request" && line["trace_id"] == traceID {
+			logged = true
+		}
+	}
+	if !logged {
+		t.Errorf("access log should carry the trace ID")
+	}
+}
+
+func spanNames(m map[string]sdktrace.ReadOnlySpan) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
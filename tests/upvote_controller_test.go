@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	confpkg "github.com/Balaji01-4D/shit-happens/internals/confession"
+	"github.com/Balaji01-4D/shit-happens/internals/upvote"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupRouterUpvote(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	r, db := setupRouter(t)
+	upvote.RegisterRoutes(r, db, testConfig())
+	return r, db
+}
+
+// voteAs sends the request from a fixed address, so consecutive calls count as the same voter
+func voteAs(r *gin.Engine, method, path, addr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = addr
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func upvoteCount(t *testing.T, r *gin.Engine, id uint) int {
+	t.Helper()
+	w := doJSONRequest(r, http.MethodGet, "/confessions/"+jsonNumber(id), nil)
+	var resp struct {
+		Upvotes int `json:"upvotes"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Upvotes
+}
+
+func TestUpvote_ToggleAndState(t *testing.T) {
+	r, _ := setupRouterUpvote(t)
+	id := createConfession(t, r, "Off by one", "the loop ran one time too many", "c", nil)
+	path := "/confessions/" + jsonNumber(id) + "/upvote"
+	const voter = "10.1.0.1:1234"
+
+	voted := func() bool {
+		w := voteAs(r, http.MethodGet, path, voter)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		var resp struct {
+			Upvoted bool `json:"upvoted"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Upvoted
+	}
+
+	if voted() {
+		t.Fatalf("expected no vote yet")
+	}
+	voteAs(r, http.MethodPost, path, voter)
+	voteAs(r, http.MethodPost, path, "10.1.0.2:1234")
+	if !voted() || upvoteCount(t, r, id) != 2 {
+		t.Fatalf("expected vote recorded, count=%d", upvoteCount(t, r, id))
+	}
+
+	if w := voteAs(r, http.MethodDelete, path, voter); !strings.Contains(w.Body.String(), "upvote removed") {
+		t.Fatalf("expected upvote removed, got %d %s", w.Code, w.Body.String())
+	}
+	if voted() || upvoteCount(t, r, id) != 1 {
+		t.Fatalf("expected vote removed, count=%d", upvoteCount(t, r, id))
+	}
+	if w := voteAs(r, http.MethodDelete, path, voter); !strings.Contains(w.Body.String(), "not upvoted") {
+		t.Fatalf("expected not upvoted, got %d %s", w.Code, w.Body.String())
+	}
+	if n := upvoteCount(t, r, id); n != 1 {
+		t.Fatalf("removing twice must not decrement again, count=%d", n)
+	}
+}
+
+func TestUpvote_UnknownConfession(t *testing.T) {
+	r, db := setupRouterUpvote(t)
+	if w := voteAs(r, http.MethodPost, "/confessions/999999/upvote", "10.1.1.1:1234"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	if w := voteAs(r, http.MethodPost, "/confessions/abc/upvote", "10.1.1.1:1234"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	var n int64
+	db.Model(&upvote.Upvote{}).Count(&n)
+	if n != 0 {
+		t.Fatalf("expected no orphan upvote rows, got %d", n)
+	}
+}
+
+func TestUpvote_ReconcileFixesDrift(t *testing.T) {
+	r, db := setupRouterUpvote(t)
+	id := createConfession(t, r, "Counter drift", "the counter and the rows disagree", "go", nil)
+	path := "/confessions/" + jsonNumber(id) + "/upvote"
+	voteAs(r, http.MethodPost, path, "10.1.2.1:1234")
+	voteAs(r, http.MethodPost, path, "10.1.2.2:1234")
+	db.Model(&confpkg.Confession{}).Where("id = ?", id).Update("upvotes", 7)
+
+	if w := doJSONRequest(r, http.MethodPost, "/admin/upvotes/reconcile", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+
+	var report struct {
+		Drifted []struct {
+			ConfessionID uint `json:"confessionId"`
+			Stored       int  `json:"stored"`
+			Actual       int  `json:"actual"`
+		} `json:"drifted"`
+		Applied bool `json:"applied"`
+	}
+	w := doAdminRequest(r, http.MethodPost, "/admin/upvotes/reconcile?dryRun=true", nil)
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	if len(report.Drifted) != 1 || report.Drifted[0].Stored != 7 || report.Drifted[0].Actual != 2 || report.Applied {
+		t.Fatalf("unexpected dry run report: %s", w.Body.String())
+	}
+	if n := upvoteCount(t, r, id); n != 7 {
+		t.Fatalf("dry run must not change counters, got %d", n)
+	}
+
+	w = doAdminRequest(r, http.MethodPost, "/admin/upvotes/reconcile", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if n := upvoteCount(t, r, id); n != 2 {
+		t.Fatalf("expected counter reconciled to 2, got %d", n)
+	}
+	w = doAdminRequest(r, http.MethodPost, "/admin/upvotes/reconcile?dryRun=true", nil)
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	if len(report.Drifted) != 0 {
+		t.Fatalf("expected no drift left: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Balaji01-4D/shit-happens/internals/events"
+	"github.com/Balaji01-4D/shit-happens/internals/webhook"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupRouterWebhook(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	r, db := setupRouter(t)
+	if err := db.AutoMigrate(&webhook.Subscription{}, &webhook.Delivery{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM webhook_deliveries")
+		db.Exec("DELETE FROM webhook_subscriptions")
+	})
+	webhook.RegisterRoutes(r, db, testConfig())
+	return r, db
+}
+
+func TestWebhooks_SignedDeliveryWithRetry(t *testing.T) {
+	r, db := setupRouterWebhook(t)
+
+	var calls atomic.Int32
+	received := make(chan webhook.Payload, 1)
+	var secret string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		ts, _ := strconv.ParseInt(req.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		if req.Header.Get(webhook.HeaderSignature) != webhook.Sign(secret, ts, body) {
+			t.Errorf("bad signature")
+		}
+		// fail the first attempt to exercise the retry
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var p webhook.Payload
+		_ = json.Unmarshal(body, &p)
+		received <- p
+	}))
+	defer receiver.Close()
+
+	if w := doJSONRequest(r, http.MethodPost, "/admin/webhooks", map[string]any{"url": receiver.URL}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+	if w := doAdminRequest(r, http.MethodPost, "/admin/webhooks", map[string]any{"url": receiver.URL, "eventTypes": []string{"nope"}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown event type, got %d", w.Code)
+	}
+	w := doAdminRequest(r, http.MethodPost, "/admin/webhooks", map[string]any{
+		"url": receiver.URL, "eventTypes": []string{events.ConfessionCreated}, "tag": "postgres",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d (%s)", w.Code, w.Body.String())
+	}
+	var sub struct {
+		ID     uint   `json:"id"`
+		Secret string `json:"secret"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &sub)
+	if sub.Secret == "" {
+		t.Fatalf("expected generated secret in create response")
+	}
+	secret = sub.Secret
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := webhook.NewDispatcher(db, events.Default)
+	d.Backoff = 10 * time.Millisecond
+	d.MaxAttempts = 3
+	go d.Run(ctx)
+	time.Sleep(20 * time.Millisecond) // let the dispatcher subscribe
+
+	createConfession(t, r, "Redis only", "not for the postgres hook", "go", []string{"redis"})
+	id := createConfession(t, r, "Lock timeout", "a migration held a lock forever", "sql", []string{"postgres"})
+
+	select {
+	case p := <-received:
+		if p.Type != events.ConfessionCreated {
+			t.Fatalf("unexpected event %q", p.Type)
+		}
+		data, _ := p.Data.(map[string]any)
+		if uint(data["id"].(float64)) != id {
+			t.Fatalf("expected the postgres confession, got %v", p.Data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("webhook not delivered")
+	}
+
+	// the failed and the successful attempt are both logged
+	var log []struct {
+		Attempt    int  `json:"attempt"`
+		StatusCode int  `json:"statusCode"`
+		Success    bool `json:"success"`
+	}
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && len(log) < 2 {
+		w = doAdminRequest(r, http.MethodGet, "/admin/webhooks/"+jsonNumber(sub.ID)+"/deliveries", nil)
+		_ = json.Unmarshal(w.Body.Bytes(), &log)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(log) != 2 || log[0].Attempt != 2 || !log[0].Success || log[1].StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected delivery log: %s", w.Body.String())
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected 2 calls, got %d", n)
+	}
+}
+
+func TestWebhooks_RunDrainsRetriesOnShutdown(t *testing.T) {
+	r, db := setupRouterWebhook(t)
+
+	var calls atomic.Int32
+	firstCall := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if calls.Add(1) == 1 {
+			close(firstCall)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+	if w := doAdminRequest(r, http.MethodPost, "/admin/webhooks", map[string]any{"url": receiver.URL}); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d (%s)", w.Code, w.Body.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := webhook.NewDispatcher(db, events.Default)
+	d.Backoff = 100 * time.Millisecond
+	d.MaxAttempts = 2
+	stopped := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(stopped)
+	}()
+	time.Sleep(20 * time.Millisecond) // let the dispatcher subscribe
+
+	createConfession(t, r, "Shutdown race", "the pool closed under a running query", "go", nil)
+	select {
+	case <-firstCall:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("webhook not called")
+	}
+
+	// cancelled while the retry is waiting out its backoff
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Run did not return")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected Run to return after the retry, got %d calls", n)
+	}
+	var delivered int64
+	db.Model(&webhook.Delivery{}).Where("success = ?", true).Count(&delivered)
+	if delivered != 1 {
+		t.Fatalf("expected the retried delivery recorded before Run returned, got %d", delivered)
+	}
+}